@@ -1,10 +1,14 @@
 package main
 
-import "circe/internal/command"
+import (
+	"os"
+
+	"circe/internal/command"
+)
 
 func main() {
 	rootCmd := command.InitialiseRootCmd()
 	if err := rootCmd.Command.Execute(); err != nil {
-		panic(err)
+		os.Exit(command.ExitCode(err))
 	}
 }
@@ -0,0 +1,13 @@
+package command
+
+// AllGenerateCommand renders the egress and ingress rows of the input in a single pass
+type AllGenerateCommand struct {
+	policyCommand
+}
+
+func NewAllCommand() *AllGenerateCommand {
+	c := &AllGenerateCommand{}
+	c.init("all", "generates egress and ingress network policies based on inputs from CSV or XLSX", "")
+	c.command.RunE = c.Run
+	return c
+}
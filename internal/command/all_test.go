@@ -0,0 +1,77 @@
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAllCommand_Run ensures the all command renders the egress and ingress rows in one pass.
+func TestAllCommand_Run(t *testing.T) {
+	var stderr bytes.Buffer
+	outDir := t.TempDir()
+	cmd := NewAllCommand()
+	cmd.command.SetErr(&stderr)
+	cmd.input = filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+	cmd.output = outDir
+	cmd.defaultDeny = true
+	if err := cmd.Run(nil, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	cases := map[string]string{
+		"frontend-to-backend.yaml":  "- Egress",
+		"allow-ingress-https.yaml":  "- Ingress",
+		"default-deny-egress.yaml":  "namespace: ns-a",
+		"default-deny-ingress.yaml": "namespace: ns-b",
+	}
+	for file, sub := range cases {
+		b, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		if !strings.Contains(string(b), sub) {
+			t.Fatalf("%s missing substring %q. Content:\n%s", file, sub, b)
+		}
+	}
+	if !strings.Contains(stderr.String(), "rendered 4 policies, skipped 0 rows") {
+		t.Fatalf("unexpected summary:\n%s", stderr.String())
+	}
+}
+
+// TestAllCommand_SingleDirectionFormat ensures formats rendering a single direction are rejected
+// instead of leaving the rows of the other direction out.
+func TestAllCommand_SingleDirectionFormat(t *testing.T) {
+	for _, format := range []string{"egressfirewall", "egressnetworkpolicy", "istio"} {
+		cmd := NewAllCommand()
+		cmd.input = filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+		cmd.output = t.TempDir()
+		cmd.format = format
+		if err := cmd.Run(nil, nil); err == nil || !strings.Contains(err.Error(), "only renders") {
+			t.Fatalf("%s: expected an error, got %v", format, err)
+		}
+	}
+}
+
+// TestAllCommand_DefaultDenyNameClash ensures a policy named like a default-deny baseline fails
+// the run before anything is written.
+func TestAllCommand_DefaultDenyNameClash(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "rules.csv")
+	csv := "direction,source_namespace,source_selector,destination_specifier,network_policy_name\n" +
+		"egress,ns-a,app=web,10.0.0.0/8,default-deny-egress\n"
+	if err := os.WriteFile(input, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewAllCommand()
+	cmd.input = input
+	cmd.output = t.TempDir()
+	cmd.defaultDeny = true
+	if got := ExitCode(cmd.Run(nil, nil)); got != ExitValidation {
+		t.Fatalf("exit code = %d, want %d", got, ExitValidation)
+	}
+	if entries, _ := os.ReadDir(cmd.output); len(entries) != 0 {
+		t.Fatalf("expected nothing rendered, got %v", entries)
+	}
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the circe binary, so pipelines can branch on the failure category
+const (
+	ExitOK              = 0
+	ExitFailure         = 1 // usage errors and anything not covered below
+	ExitInputUnreadable = 2 // the input file is missing, has an unsupported extension or cannot be parsed
+	ExitValidation      = 3 // a row is invalid or cannot be represented by the selected format
+	ExitRender          = 4 // a policy could not be rendered or written to the output directory
+	ExitNothingToRender = 5 // no row produced a policy for the requested direction
+)
+
+// ExitError is an error carrying the exit code of the process
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitError wraps err, annotated with a formatted message, into an ExitError with the given code
+func exitError(code int, err error, format string, args ...any) error {
+	return &ExitError{Code: code, Err: fmt.Errorf(format+": %w", append(args, err)...)}
+}
+
+// ExitCode returns the exit code for an error returned by a command: ExitOK for nil,
+// the code of an ExitError, and ExitFailure otherwise
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
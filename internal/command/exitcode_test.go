@@ -0,0 +1,85 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExitCodes ensures each failure category of the generate commands maps to its own exit code.
+func TestExitCodes(t *testing.T) {
+	sample := filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+	egressOnly := filepath.Join(t.TempDir(), "egress.csv")
+	if err := os.WriteFile(egressOnly, []byte("direction,source_namespace,source_selector,destination_specifier,destination_ports,network_policy_name\negress,ns-a,app=web,10.0.0.0/24,443,web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.csv")
+	if err := os.WriteFile(invalid, []byte("direction,destination_namespace,destination_selector,source_specifier,destination_ports,network_policy_name\ningress,ns-a,app=web,10.0.0.0/33,443,web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		input  string
+		output string
+		want   int
+	}{
+		"ok":                {sample, t.TempDir(), ExitOK},
+		"missing input":     {filepath.Join(t.TempDir(), "missing.csv"), t.TempDir(), ExitInputUnreadable},
+		"unknown extension": {filepath.Join(t.TempDir(), "rules.txt"), t.TempDir(), ExitInputUnreadable},
+		"invalid row":       {invalid, t.TempDir(), ExitValidation},
+		"missing output":    {sample, filepath.Join(t.TempDir(), "missing"), ExitRender},
+		"no ingress rows":   {egressOnly, t.TempDir(), ExitNothingToRender},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cmd := NewIngressCommand()
+			cmd.input = tc.input
+			cmd.output = tc.output
+			if got := ExitCode(cmd.Run(nil, nil)); got != tc.want {
+				t.Fatalf("exit code = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestExitCodes_DefaultDenyFormat ensures a default deny the format cannot express is reported
+// as a validation failure before any policy is written.
+func TestExitCodes_DefaultDenyFormat(t *testing.T) {
+	cmd := NewEgressCommand()
+	cmd.input = filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+	cmd.output = t.TempDir()
+	cmd.format = "anp"
+	cmd.defaultDeny = true
+	if got := ExitCode(cmd.Run(nil, nil)); got != ExitValidation {
+		t.Fatalf("exit code = %d, want %d", got, ExitValidation)
+	}
+	if entries, _ := os.ReadDir(cmd.output); len(entries) != 0 {
+		t.Fatalf("expected nothing rendered, got %v", entries)
+	}
+}
+
+// TestExitCodes_UnknownFormat ensures unknown formats are usage errors reported before the input
+// is read, so nothing is written.
+func TestExitCodes_UnknownFormat(t *testing.T) {
+	sample := filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+	for _, tc := range []struct{ format, nodeFormat string }{{"foo", ""}, {"kubernetes", "anp"}} {
+		cmd := NewAllCommand()
+		cmd.input = sample
+		cmd.output = t.TempDir()
+		cmd.format, cmd.nodeFormat = tc.format, tc.nodeFormat
+		if got := ExitCode(cmd.Run(nil, nil)); got != ExitFailure {
+			t.Fatalf("%+v: exit code = %d, want %d", tc, got, ExitFailure)
+		}
+		if entries, _ := os.ReadDir(cmd.output); len(entries) != 0 {
+			t.Fatalf("%+v: expected nothing rendered, got %v", tc, entries)
+		}
+
+		lint := NewLintCommand()
+		lint.input = sample
+		lint.report = reportHuman
+		lint.format, lint.nodeFormat = tc.format, tc.nodeFormat
+		if err := lint.Run(nil, nil); err == nil {
+			t.Fatalf("%+v: expected lint to fail", tc)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package command
+
+import (
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// inputFlags select and parse the input sheet; every command reading one shares them
+type inputFlags struct {
+	input       string
+	headerStart int
+}
+
+func (f *inputFlags) register(command *cobra.Command) {
+	command.Flags().StringVarP(&f.input, "input", "i", "", "input file (CSV or XLSX)")
+	command.Flags().IntVarP(&f.headerStart, "header", "", 0, "header starting index in the input (CSV/XLSX), indicating which row to treat as header; default is 0")
+}
+
+// read unmarshals the rows of the input sheet
+func (f *inputFlags) read() ([]unmarshalcsv.UnmarshalledData, error) {
+	var unmarshalled []unmarshalcsv.UnmarshalledData
+	if err := unmarshalcsv.Unmarshal(&unmarshalled, f.input, f.headerStart); err != nil {
+		return nil, exitError(ExitInputUnreadable, err, "failed to read %s", f.input)
+	}
+	return unmarshalled, nil
+}
+
+// policyCommand implements the network-policy subcommands, which only differ by the direction
+// of the rows they render
+type policyCommand struct {
+	command   *cobra.Command
+	direction string // "Egress", "Ingress" or empty for both
+	inputFlags
+	output      string
+	canonical   bool
+	defaultDeny bool
+	format      string
+	nodeFormat  string
+	allowDNS    bool
+	strict      bool
+}
+
+// formatHelp lists the output formats available to each direction
+var formatHelp = map[string]string{
+	"Egress":  "output format: kubernetes (NetworkPolicy), cilium (CiliumNetworkPolicy), calico (projectcalico.org/v3 NetworkPolicy), anp (AdminNetworkPolicy), egressfirewall (OVN-Kubernetes EgressFirewall) or egressnetworkpolicy (OpenShift SDN EgressNetworkPolicy)",
+	"Ingress": "output format: kubernetes (NetworkPolicy), cilium (CiliumNetworkPolicy), calico (projectcalico.org/v3 NetworkPolicy), anp (AdminNetworkPolicy) or istio (Istio AuthorizationPolicy)",
+	"":        "output format: kubernetes (NetworkPolicy), cilium (CiliumNetworkPolicy), calico (projectcalico.org/v3 NetworkPolicy) or anp (AdminNetworkPolicy)",
+}
+
+// init creates the cobra command and binds its flags to c, which must therefore not be copied
+// afterwards
+func (c *policyCommand) init(use, short, direction string) {
+	c.command = &cobra.Command{
+		Use:   use,
+		Short: short,
+	}
+	c.direction = direction
+	c.inputFlags.register(c.command)
+	c.command.Flags().StringVarP(&c.output, "output", "o", ".", "output directory to save the policies, default is current directory")
+	c.command.Flags().BoolVarP(&c.canonical, "canonical-cidrs", "", false, "mask host bits of CIDRs (10.0.0.5/24 becomes 10.0.0.0/24) instead of failing")
+	switch direction {
+	case "Egress":
+		c.command.Flags().BoolVarP(&c.defaultDeny, "default-deny", "", false, "also render a default-deny-egress policy for every subject namespace (a trailing deny-all rule for the egress firewall formats)")
+	case "Ingress":
+		c.command.Flags().BoolVarP(&c.defaultDeny, "default-deny", "", false, "also render a default-deny-ingress policy for every subject namespace")
+	default:
+		c.command.Flags().BoolVarP(&c.defaultDeny, "default-deny", "", false, "also render default-deny-ingress and default-deny-egress policies for every subject namespace")
+	}
+	if direction != "Ingress" {
+		c.command.Flags().BoolVarP(&c.allowDNS, "allow-dns", "", false, "allow DNS to kube-dns in the default-deny-egress policies (with --default-deny)")
+	}
+	c.command.Flags().StringVarP(&c.format, "format", "f", netpol.FormatKubernetes, formatHelp[direction])
+	c.command.Flags().StringVarP(&c.nodeFormat, "node-format", "", "", "resource used for rows with node_role: calico (GlobalNetworkPolicy on host endpoints) or cilium (CiliumClusterwideNetworkPolicy); follows --format for cilium, calico otherwise")
+	c.command.Flags().BoolVarP(&c.strict, "strict", "", false, "fail on warnings, such as rows that are skipped (missing name, subject or unknown direction), instead of printing them")
+}
+
+func (c *policyCommand) Run(command *cobra.Command, args []string) error {
+	if c.direction == "" {
+		// Formats for a single direction would leave the rows of the other one out
+		switch strings.ToLower(c.format) {
+		case netpol.FormatEgressFirewall, netpol.FormatEgressNetworkPolicy:
+			return fmt.Errorf("format %s only renders egress rows, use network-policy egress", c.format)
+		case netpol.FormatIstio:
+			return fmt.Errorf("format %s only renders ingress rows, use network-policy ingress", c.format)
+		}
+	}
+	opts := netpol.Options{
+		Direction:      c.direction,
+		CanonicalCIDRs: c.canonical,
+		Format:         c.format,
+		NodeFormat:     c.nodeFormat,
+		AllowDNS:       c.allowDNS,
+	}
+	if err := opts.CheckFormats(); err != nil {
+		return err
+	}
+	unmarshalled, err := c.read()
+	if err != nil {
+		return err
+	}
+	defaultDeny := c.defaultDeny
+	switch strings.ToLower(c.format) {
+	case netpol.FormatEgressFirewall, netpol.FormatEgressNetworkPolicy:
+		// One egress firewall per namespace: the baseline is a trailing deny-all rule
+		opts.DenyAll, defaultDeny = defaultDeny, false
+	}
+	problems := netpol.Validate(unmarshalled, opts)
+	if err := checkProblems(c.command.ErrOrStderr(), c.input, problems, c.strict); err != nil {
+		return err
+	}
+	n, err := netpol.NewGenericPoliciesWithOptions(unmarshalled, c.output, opts)
+	if err != nil {
+		return exitError(ExitValidation, err, "invalid input %s", c.input)
+	}
+	// Built before rendering, so a name clash fails the run before anything is written
+	var baseline *netpol.NetworkPolicy
+	if defaultDeny {
+		if baseline, err = netpol.NewDefaultDenyPolicies(n); err != nil {
+			return exitError(ExitValidation, err, "invalid input %s", c.input)
+		}
+	}
+	if err := n.RenderGeneric(); err != nil {
+		if errors.Is(err, netpol.ErrNoPolicies) {
+			rows := "rows"
+			if c.direction != "" {
+				rows = strings.ToLower(c.direction) + " rows"
+			}
+			return exitError(ExitNothingToRender, err, "no %s in %s", rows, c.input)
+		}
+		return exitError(ExitRender, err, "failed to render policies to %s", c.output)
+	}
+	rendered := n.Len()
+	if baseline != nil && baseline.Len() > 0 {
+		if err := baseline.RenderGeneric(); err != nil {
+			return exitError(ExitRender, err, "failed to render default-deny policies to %s", c.output)
+		}
+		rendered += baseline.Len()
+	}
+	printSummary(c.command.ErrOrStderr(), rendered, countSkipped(problems))
+	return nil
+}
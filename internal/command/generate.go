@@ -0,0 +1,209 @@
+package command
+
+import (
+	"circe/pkg/unmarshalcsv"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xuri/excelize/v2"
+)
+
+// sampleSheet is the name of the sheet of a generated XLSX file
+const sampleSheet = "policies"
+
+// columnHelp explains each column of the input sheet; it is added as a comment to the header
+// cells of a generated XLSX file
+var columnHelp = map[string]string{
+	"direction":             "egress (rules for traffic leaving the subject pods) or ingress (traffic reaching them)",
+	"source_specifier":      "ingress: comma-separated CIDRs or IPs allowed to connect, e.g. 10.1.0.0/24; exclude sub-blocks with ! (10.0.0.0/8!10.96.0.0/12)",
+	"destination_namespace": "ingress: namespace of the subject pods (* for every namespace); egress: namespaces of the peer pods",
+	"destination_selector":  "ingress: label selector of the subject pods, e.g. app=backend; egress: selector of the peer pods",
+	"destination_protocol":  "TCP (default), UDP or SCTP; several protocols can be comma-separated",
+	"destination_ports":     "comma-separated ports (443), ranges (8000-8100), named ports (http) or protocol/port (UDP/53); empty allows every port",
+	"source_namespace":      "egress: namespace of the subject pods (* for every namespace); ingress: namespaces of the peer pods",
+	"source_selector":       "egress: label selector of the subject pods, e.g. app=frontend; ingress: selector of the peer pods",
+	"node_role":             "optional: node role (worker) or node selector; the row then applies to the selected nodes instead of pods",
+	"destination_specifier": "egress: comma-separated CIDRs, IPs or hostnames (api.example.com, *.example.com) to allow",
+	"comment":               "optional: free text, rendered as the circe/comment annotation",
+	"network_policy_name":   "name of the generated policy (DNS-1123: lowercase alphanumerics, '-' or '.'); rows sharing a name are merged",
+	"owner":                 "optional: owner, rendered as the circe/owner annotation",
+	"ticket":                "optional: change ticket, rendered as the circe/ticket annotation",
+	"labels":                "optional: comma-separated key=value labels of the generated policy",
+	"order":                 "optional, calico: policy order (lower is evaluated first)",
+	"action":                "optional: allow (default), deny, pass or log, depending on the output format",
+	"priority":              "optional, anp: AdminNetworkPolicy priority (0-1000); empty renders the BaselineAdminNetworkPolicy",
+	"http_methods":          "optional, istio: comma-separated HTTP methods, e.g. GET,POST",
+	"http_paths":            "optional, istio: comma-separated HTTP paths, e.g. /api/*",
+}
+
+// sampleRows are the example rows of a generated file, keyed by column
+var sampleRows = []map[string]string{
+	{
+		"direction":             "egress",
+		"source_namespace":      "ns-a",
+		"source_selector":       "app=frontend",
+		"destination_namespace": "ns-b",
+		"destination_selector":  "app=backend",
+		"destination_protocol":  "TCP",
+		"destination_ports":     "8080",
+		"comment":               "frontend calls the backend API",
+		"network_policy_name":   "frontend-to-backend",
+	},
+	{
+		"direction":             "egress",
+		"source_namespace":      "ns-a",
+		"source_selector":       "app=frontend",
+		"destination_specifier": "10.0.0.0/24",
+		"destination_protocol":  "TCP",
+		"destination_ports":     "443",
+		"comment":               "frontend calls the payment gateway",
+		"network_policy_name":   "frontend-to-backend",
+	},
+	{
+		"direction":             "ingress",
+		"source_specifier":      "10.1.0.0/24",
+		"destination_namespace": "ns-b",
+		"destination_selector":  "app=backend",
+		"destination_protocol":  "TCP",
+		"destination_ports":     "443",
+		"comment":               "load balancer subnet",
+		"network_policy_name":   "allow-ingress-https",
+	},
+}
+
+type GenerateCommand struct {
+	command *cobra.Command
+	csv     string
+	xlsx    string
+	force   bool
+}
+
+func NewGenerateCommand() *GenerateCommand {
+	c := &GenerateCommand{
+		command: &cobra.Command{
+			Use:   "generate",
+			Short: "writes starter CSV and/or XLSX input files with the canonical header and example rows",
+		},
+	}
+	c.command.Flags().StringVarP(&c.csv, "csv", "", "", "path of the CSV file to write")
+	c.command.Flags().StringVarP(&c.xlsx, "xlsx", "", "", "path of the XLSX file to write, with a frozen header, column comments and dropdowns")
+	c.command.Flags().BoolVarP(&c.force, "force", "", false, "overwrite existing files")
+	c.command.RunE = c.Run
+	return c
+}
+
+func (c *GenerateCommand) Run(command *cobra.Command, args []string) error {
+	if c.csv == "" && c.xlsx == "" {
+		return errors.New("nothing to generate: set --csv and/or --xlsx")
+	}
+	for _, path := range []string{c.csv, c.xlsx} {
+		if path == "" || c.force {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+	}
+	records := sampleRecords()
+	if c.csv != "" {
+		if err := writeSampleCSV(c.csv, records); err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.csv, err)
+		}
+	}
+	if c.xlsx != "" {
+		if err := writeSampleXLSX(c.xlsx, records); err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.xlsx, err)
+		}
+	}
+	return nil
+}
+
+// sampleRecords returns the canonical header followed by the example rows
+func sampleRecords() [][]string {
+	header := unmarshalcsv.Header()
+	records := [][]string{header}
+	for _, sample := range sampleRows {
+		row := make([]string, len(header))
+		for i, col := range header {
+			row[i] = sample[col]
+		}
+		records = append(records, row)
+	}
+	return records
+}
+
+func writeSampleCSV(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSampleXLSX writes the records to a single sheet with a frozen bold header, a comment
+// explaining every column and dropdowns for the direction and destination_protocol columns
+func writeSampleXLSX(path string, records [][]string) error {
+	f := excelize.NewFile()
+	defer func() { _ = f.Close() }()
+	if err := f.SetSheetName(f.GetSheetName(0), sampleSheet); err != nil {
+		return err
+	}
+	for i, record := range records {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sampleSheet, cell, &record); err != nil {
+			return err
+		}
+	}
+
+	header := records[0]
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	last, _ := excelize.CoordinatesToCellName(len(header), 1)
+	if err := f.SetCellStyle(sampleSheet, "A1", last, bold); err != nil {
+		return err
+	}
+	if err := f.SetPanes(sampleSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	for i, col := range header {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.AddComment(sampleSheet, excelize.Comment{Cell: cell, Author: "circe", Text: columnHelp[col]}); err != nil {
+			return err
+		}
+		var dv *excelize.DataValidation
+		switch col {
+		case "direction":
+			dv = excelize.NewDataValidation(false)
+			if err := dv.SetDropList([]string{"egress", "ingress"}); err != nil {
+				return err
+			}
+			dv.SetError(excelize.DataValidationErrorStyleStop, "Invalid direction", "Use egress or ingress.")
+		case "destination_protocol":
+			dv = excelize.NewDataValidation(true)
+			if err := dv.SetDropList([]string{"TCP", "UDP", "SCTP"}); err != nil {
+				return err
+			}
+			// A warning only, as several protocols can be comma-separated
+			dv.SetError(excelize.DataValidationErrorStyleWarning, "Unknown protocol", "Use TCP, UDP or SCTP, or several of them comma-separated.")
+		default:
+			continue
+		}
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		dv.SetSqref(fmt.Sprintf("%s2:%s%d", column, column, excelize.TotalRows))
+		if err := f.AddDataValidation(sampleSheet, dv); err != nil {
+			return err
+		}
+	}
+	return f.SaveAs(path)
+}
@@ -0,0 +1,115 @@
+package command
+
+import (
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// TestColumnHelp ensures every column of the input sheet is explained in generated XLSX files.
+func TestColumnHelp(t *testing.T) {
+	for _, col := range unmarshalcsv.Header() {
+		if columnHelp[col] == "" {
+			t.Errorf("column %s has no help", col)
+		}
+	}
+}
+
+// TestGenerateCommand_Run ensures the generated files hold the canonical header and example rows
+// that lint cleanly and render both directions.
+func TestGenerateCommand_Run(t *testing.T) {
+	dir := t.TempDir()
+	cmd := NewGenerateCommand()
+	cmd.csv = filepath.Join(dir, "sample.csv")
+	cmd.xlsx = filepath.Join(dir, "sample.xlsx")
+	if err := cmd.Run(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, input := range []string{cmd.csv, cmd.xlsx} {
+		var rows []unmarshalcsv.UnmarshalledData
+		if err := unmarshalcsv.Unmarshal(&rows, input, 0); err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if len(rows) != len(sampleRows) {
+			t.Fatalf("%s: got %d rows, want %d", input, len(rows), len(sampleRows))
+		}
+		if problems := netpol.Validate(rows, netpol.Options{}); len(problems) > 0 {
+			t.Fatalf("%s: unexpected problems: %v", input, problems)
+		}
+		for _, direction := range []string{"Egress", "Ingress"} {
+			n, err := netpol.NewGenericPoliciesWithOptions(rows, dir, netpol.Options{Direction: direction})
+			if err != nil {
+				t.Fatalf("%s: %v", input, err)
+			}
+			if n.Len() == 0 {
+				t.Fatalf("%s: no %s policy", input, direction)
+			}
+		}
+	}
+
+	// Existing files are only overwritten with --force
+	if err := NewGenerateCommand().Run(nil, nil); err == nil {
+		t.Fatal("expected an error without --csv nor --xlsx")
+	}
+	again := NewGenerateCommand()
+	again.csv = cmd.csv
+	if err := again.Run(nil, nil); err == nil {
+		t.Fatal("expected an error overwriting an existing file")
+	}
+	again.force = true
+	if err := again.Run(nil, nil); err != nil {
+		t.Fatalf("unexpected error with --force: %v", err)
+	}
+}
+
+// TestWriteSampleXLSX ensures the header of a generated XLSX file is frozen and commented, and
+// direction and destination_protocol offer dropdowns.
+func TestWriteSampleXLSX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.xlsx")
+	if err := writeSampleXLSX(path, sampleRecords()); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	panes, err := f.GetPanes(sampleSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !panes.Freeze || panes.YSplit != 1 {
+		t.Fatalf("header not frozen: %+v", panes)
+	}
+
+	comments, err := f.GetComments(sampleSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != len(unmarshalcsv.Header()) {
+		t.Fatalf("got %d comments, want one per column", len(comments))
+	}
+
+	validations, err := f.GetDataValidations(sampleSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lists := map[string]string{}
+	for _, dv := range validations {
+		lists[dv.Sqref] = dv.Formula1
+	}
+	// direction is column A and destination_protocol column E
+	for sqref, list := range map[string]string{
+		"A2:A1048576": "egress,ingress",
+		"E2:E1048576": "TCP,UDP,SCTP",
+	} {
+		if got := lists[sqref]; got != `"`+list+`"` {
+			t.Errorf("data validation %s = %q, want %q", sqref, got, list)
+		}
+	}
+}
@@ -22,7 +22,9 @@ func TestIngressCommand_Run(t *testing.T) {
         cmd.input = csvPath
         cmd.output = outDir
         cmd.headerStart = 0
-        cmd.Run(nil, nil)
+        if err := cmd.Run(nil, nil); err != nil {
+            t.Fatalf("run: %v", err)
+        }
 
         outFile := filepath.Join(outDir, "allow-ingress-https.yaml")
         b, err := os.ReadFile(outFile)
@@ -63,7 +65,9 @@ func TestIngressCommand_Run(t *testing.T) {
         cmd.input = xlsxPath
         cmd.output = outDir
         cmd.headerStart = 0
-        cmd.Run(nil, nil)
+        if err := cmd.Run(nil, nil); err != nil {
+            t.Fatalf("run: %v", err)
+        }
 
         outFile := filepath.Join(outDir, "allow-ingress-https.yaml")
         b, err := os.ReadFile(outFile)
@@ -3,9 +3,13 @@ package command
 func InitialiseRootCmd() *RootCommand {
 	rootCommand := NewRootCommand()
 	networkPolicyCommand := NewNetworkPolicyCmd()
+	lintCmd := NewLintCommand()
+	generateCmd := NewGenerateCommand()
 	versionCmd := NewVersionCmd()
 	rootCommand.Command.AddCommand(
 		networkPolicyCommand.commnad,
+		lintCmd.command,
+		generateCmd.command,
 		versionCmd.command,
 	)
 	return rootCommand
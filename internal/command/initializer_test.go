@@ -0,0 +1,68 @@
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInitialiseRootCmd ensures the flags parsed by cobra reach the commands, which the tests
+// setting the command fields directly cannot catch.
+func TestInitialiseRootCmd(t *testing.T) {
+	input := filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+	cases := []struct {
+		args  []string
+		files []string
+	}{
+		{[]string{"network-policy", "egress", "--default-deny"}, []string{"frontend-to-backend.yaml", "default-deny-egress.yaml"}},
+		{[]string{"network-policy", "ingress", "--default-deny"}, []string{"allow-ingress-https.yaml", "default-deny-ingress.yaml"}},
+		{[]string{"network-policy", "all", "-f", "cilium"}, []string{"frontend-to-backend.yaml", "allow-ingress-https.yaml"}},
+	}
+	for _, tc := range cases {
+		t.Run(strings.Join(tc.args[:2], " "), func(t *testing.T) {
+			var stderr bytes.Buffer
+			outDir := t.TempDir()
+			root := InitialiseRootCmd().Command
+			root.SetErr(&stderr)
+			root.SetArgs(append(tc.args, "-i", input, "-o", outDir, "--strict"))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("execute: %v\n%s", err, stderr.String())
+			}
+			for _, file := range tc.files {
+				if _, err := os.Stat(filepath.Join(outDir, file)); err != nil {
+					t.Fatalf("expected %s: %v", file, err)
+				}
+			}
+			if !strings.Contains(stderr.String(), "rendered") {
+				t.Fatalf("missing summary:\n%s", stderr.String())
+			}
+		})
+	}
+
+	t.Run("lint", func(t *testing.T) {
+		var stdout bytes.Buffer
+		root := InitialiseRootCmd().Command
+		root.SetOut(&stdout)
+		root.SetArgs([]string{"lint", "-i", input, "--report", "json"})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		if !strings.Contains(stdout.String(), `"problems": []`) {
+			t.Fatalf("unexpected report:\n%s", stdout.String())
+		}
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sample.xlsx")
+		root := InitialiseRootCmd().Command
+		root.SetArgs([]string{"generate", "--xlsx", path})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s: %v", path, err)
+		}
+	})
+}
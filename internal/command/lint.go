@@ -0,0 +1,92 @@
+package command
+
+import (
+	"circe/pkg/netpol"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Report formats of the lint command
+const (
+	reportHuman = "human"
+	reportJSON  = "json"
+)
+
+type LintCommand struct {
+	command *cobra.Command
+	inputFlags
+	canonical  bool
+	format     string
+	nodeFormat string
+	report     string
+	strict     bool
+}
+
+// lintReport is the JSON report of the lint command
+type lintReport struct {
+	Input    string           `json:"input"`
+	Problems []netpol.Problem `json:"problems"`
+}
+
+func NewLintCommand() *LintCommand {
+	c := &LintCommand{
+		command: &cobra.Command{
+			Use:   "lint",
+			Short: "validates a CSV or XLSX input and reports every problem without rendering anything",
+		},
+	}
+	c.inputFlags.register(c.command)
+	c.command.Flags().BoolVarP(&c.canonical, "canonical-cidrs", "", false, "accept CIDRs with host bits set, as the generate commands do with this flag")
+	c.command.Flags().StringVarP(&c.format, "format", "f", netpol.FormatKubernetes, "output format the rows are checked against, as accepted by the network-policy commands")
+	c.command.Flags().StringVarP(&c.nodeFormat, "node-format", "", "", "resource the node_role rows are checked against: calico or cilium")
+	c.command.Flags().StringVarP(&c.report, "report", "", reportHuman, "report format: human or json")
+	c.command.Flags().BoolVarP(&c.strict, "strict", "", false, "also fail on warnings, such as rows that would be skipped")
+	c.command.RunE = c.Run
+	return c
+}
+
+func (c *LintCommand) Run(command *cobra.Command, args []string) error {
+	if c.report != reportHuman && c.report != reportJSON {
+		return fmt.Errorf("unsupported report format %q (expected %s or %s)", c.report, reportHuman, reportJSON)
+	}
+	opts := netpol.Options{
+		CanonicalCIDRs: c.canonical,
+		Format:         c.format,
+		NodeFormat:     c.nodeFormat,
+	}
+	if err := opts.CheckFormats(); err != nil {
+		return err
+	}
+	unmarshalled, err := c.read()
+	if err != nil {
+		return err
+	}
+	problems := netpol.Validate(unmarshalled, opts)
+
+	out := c.command.OutOrStdout()
+	if c.report == reportJSON {
+		report := lintReport{Input: c.input, Problems: problems}
+		if report.Problems == nil {
+			report.Problems = []netpol.Problem{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(out, "%s: %s: %s\n", p.Severity, c.input, p)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintf(out, "%s: no problems found\n", c.input)
+		}
+	}
+	warnings := countWarnings(problems)
+	if errs := len(problems) - warnings; errs > 0 || (c.strict && warnings > 0) {
+		return &ExitError{Code: ExitValidation, Err: fmt.Errorf("%s: %d error(s), %d warning(s)", c.input, errs, warnings)}
+	}
+	return nil
+}
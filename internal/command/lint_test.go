@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLintCommand_Run ensures lint reports every problem in both report formats, exits with the
+// validation code and renders nothing.
+func TestLintCommand_Run(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "rules.csv")
+	csv := "direction,source_namespace,source_selector,destination_specifier,destination_ports,network_policy_name\n" +
+		"egress,ns-a,app=web,10.0.0.0/33,443,web\n" +
+		"egress,ns-a,app=web,10.0.0.0/8,80x,Web\n"
+	if err := os.WriteFile(input, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("human", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewLintCommand()
+		cmd.command.SetOut(&out)
+		cmd.input = input
+		cmd.report = reportHuman
+		if got := ExitCode(cmd.Run(nil, nil)); got != ExitValidation {
+			t.Fatalf("exit code = %d, want %d", got, ExitValidation)
+		}
+		for _, sub := range []string{
+			"row 2, column destination_specifier: invalid CIDR",
+			"row 3, column network_policy_name: invalid name",
+			"row 3, column destination_ports: named port",
+		} {
+			if !strings.Contains(out.String(), sub) {
+				t.Fatalf("report missing %q:\n%s", sub, out.String())
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewLintCommand()
+		cmd.command.SetOut(&out)
+		cmd.input = input
+		cmd.report = reportJSON
+		if got := ExitCode(cmd.Run(nil, nil)); got != ExitValidation {
+			t.Fatalf("exit code = %d, want %d", got, ExitValidation)
+		}
+		var report lintReport
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("invalid JSON report: %v\n%s", err, out.String())
+		}
+		if len(report.Problems) != 3 || report.Problems[0].Row != 2 || report.Problems[0].Column != "destination_specifier" {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewLintCommand()
+		cmd.command.SetOut(&out)
+		cmd.input = filepath.Join("..", "..", "pkg", "unmarshalcsv", "testdata", "sample.csv")
+		cmd.report = reportHuman
+		if err := cmd.Run(nil, nil); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if !strings.Contains(out.String(), "no problems found") {
+			t.Fatalf("unexpected report:\n%s", out.String())
+		}
+	})
+}
@@ -15,9 +15,11 @@ func NewNetworkPolicyCmd() *NetworkPolicyCmd {
 	}
 	egressGen := NewEgressCommand()
 	ingressGen := NewIngressCommand()
+	allGen := NewAllCommand()
 	c.commnad.AddCommand(
 		egressGen.command,
 		ingressGen.command,
+		allGen.command,
 	)
 	return c
 }
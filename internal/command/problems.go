@@ -0,0 +1,58 @@
+package command
+
+import (
+	"circe/pkg/netpol"
+	"fmt"
+	"io"
+)
+
+// checkProblems fails with every error found by netpol.Validate and prints the warnings, such as
+// skipped rows, to w. In strict mode the warnings fail the run as well.
+func checkProblems(w io.Writer, input string, problems []netpol.Problem, strict bool) error {
+	var failures []netpol.Problem
+	for _, p := range problems {
+		if p.Severity == netpol.SeverityWarning && !strict {
+			fmt.Fprintf(w, "warning: %s: %s\n", input, p)
+			continue
+		}
+		failures = append(failures, p)
+	}
+	if len(failures) > 0 {
+		return exitError(ExitValidation, &netpol.ValidationError{Problems: failures}, "invalid input %s", input)
+	}
+	return nil
+}
+
+// countSkipped returns the number of rows reported as skipped
+func countSkipped(problems []netpol.Problem) int {
+	n := 0
+	for _, p := range problems {
+		if p.Skipped {
+			n++
+		}
+	}
+	return n
+}
+
+// countWarnings returns the number of warnings, skipped rows included
+func countWarnings(problems []netpol.Problem) int {
+	n := 0
+	for _, p := range problems {
+		if p.Severity == netpol.SeverityWarning {
+			n++
+		}
+	}
+	return n
+}
+
+// printSummary prints the number of rendered policies and skipped rows at the end of a run
+func printSummary(w io.Writer, rendered, skipped int) {
+	fmt.Fprintf(w, "rendered %d %s, skipped %d %s\n", rendered, plural(rendered, "policy", "policies"), skipped, plural(skipped, "row", "rows"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
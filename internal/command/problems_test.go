@@ -0,0 +1,82 @@
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSkippedRowsReport ensures skipped rows are reported as warnings with a summary, and fail
+// the run in strict mode before anything is rendered.
+func TestSkippedRowsReport(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "rules.csv")
+	csv := "direction,source_namespace,source_selector,destination_specifier,destination_ports,network_policy_name\n" +
+		"egress,ns-a,app=web,10.0.0.0/8,443,web\n" +
+		"egress,ns-a,app=web,10.0.0.0/8,443,\n" +
+		"egres,ns-a,app=web,10.0.0.0/8,443,api\n"
+	if err := os.WriteFile(input, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := NewEgressCommand()
+	cmd.command.SetErr(&stderr)
+	cmd.input = input
+	cmd.output = t.TempDir()
+	if err := cmd.Run(nil, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, sub := range []string{
+		"warning: " + input + ": row 3, column network_policy_name: row skipped",
+		"warning: " + input + ": row 4, column direction: row skipped",
+		"rendered 1 policy, skipped 2 rows",
+	} {
+		if !strings.Contains(stderr.String(), sub) {
+			t.Fatalf("output missing %q:\n%s", sub, stderr.String())
+		}
+	}
+
+	cmd = NewEgressCommand()
+	cmd.input = input
+	cmd.output = t.TempDir()
+	cmd.strict = true
+	if got := ExitCode(cmd.Run(nil, nil)); got != ExitValidation {
+		t.Fatalf("exit code = %d, want %d", got, ExitValidation)
+	}
+	if entries, _ := os.ReadDir(cmd.output); len(entries) != 0 {
+		t.Fatalf("strict mode must not render anything, got %d files", len(entries))
+	}
+}
+
+// TestMistypedCellWarning ensures a valid but likely mistyped cell is reported as a warning, is
+// rendered as written and is not counted as a skipped row.
+func TestMistypedCellWarning(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "rules.csv")
+	csv := "direction,source_namespace,source_selector,destination_specifier,destination_ports,network_policy_name\n" +
+		"egress,ns-a,app=web,10.0.0.0/8,80x,web\n"
+	if err := os.WriteFile(input, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := NewEgressCommand()
+	cmd.command.SetErr(&stderr)
+	cmd.input = input
+	cmd.output = t.TempDir()
+	if err := cmd.Run(nil, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, sub := range []string{
+		"warning: " + input + ": row 2, column destination_ports: named port \"80x\"",
+		"rendered 1 policy, skipped 0 rows",
+	} {
+		if !strings.Contains(stderr.String(), sub) {
+			t.Fatalf("output missing %q:\n%s", sub, stderr.String())
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(cmd.output, "web.yaml")); err != nil || !strings.Contains(string(b), "port: 80x") {
+		t.Fatalf("expected the named port to be rendered: %v\n%s", err, b)
+	}
+}
@@ -32,6 +32,8 @@ func NewRootCommand() *RootCommand {
 			Short:   "Circe: CLI tool for conversion",
 			Long:    ``,
 			Version: versionStr,
+			// Errors are printed by cobra; the usage only helps with flag errors
+			SilenceUsage: true,
 		},
 	}
 	c.Command.SetVersionTemplate("circe version {{.Version}}\n")
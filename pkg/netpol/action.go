@@ -0,0 +1,63 @@
+package netpol
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rule actions of the action column
+const (
+	ActionAllow = "Allow"
+	ActionDeny  = "Deny"
+	ActionPass  = "Pass" // hand the decision to the next tier or to the namespaced policies
+	ActionLog   = "Log"  // log the matching traffic without deciding on it (Calico, Istio AUDIT)
+)
+
+// formatActions lists the rule actions each output format can represent
+var formatActions = map[string][]string{
+	FormatKubernetes: {ActionAllow},
+	FormatCilium:     {ActionAllow, ActionDeny},
+	FormatCalico:     {ActionAllow, ActionDeny, ActionPass, ActionLog},
+	FormatANP:        {ActionAllow, ActionDeny, ActionPass},
+	FormatIstio:      {ActionAllow, ActionDeny, ActionLog},
+
+	FormatEgressFirewall:      {ActionAllow, ActionDeny},
+	FormatEgressNetworkPolicy: {ActionAllow, ActionDeny},
+}
+
+// parseAction parses the action cell; an empty cell allows the traffic
+func parseAction(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "allow":
+		return ActionAllow, nil
+	case "deny":
+		return ActionDeny, nil
+	case "pass":
+		return ActionPass, nil
+	case "log":
+		return ActionLog, nil
+	default:
+		return "", fmt.Errorf("unsupported action %q (expected allow, deny, pass or log)", s)
+	}
+}
+
+// checkAction reports an action the output format cannot represent, naming the formats that can
+func checkAction(action, format string) error {
+	actions, ok := formatActions[format]
+	if !ok || slices.Contains(actions, action) {
+		return nil // unknown formats are reported by Options.CheckFormats
+	}
+	var supported []string
+	for _, f := range []string{FormatCalico, FormatCilium, FormatANP, FormatIstio} {
+		if slices.Contains(formatActions[f], action) {
+			supported = append(supported, f)
+		}
+	}
+	alternatives := strings.Join(supported, " or ")
+	if n := len(supported); n > 2 {
+		alternatives = strings.Join(supported[:n-1], ", ") + " or " + supported[n-1]
+	}
+	return fmt.Errorf("action %s cannot be represented by the %s format, which only supports %s; use --format %s",
+		action, format, strings.Join(actions, ", "), alternatives)
+}
@@ -0,0 +1,86 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestActionColumn ensures the action column is rendered by the backends that support it and
+// rejected with a precise error by the ones that don't.
+func TestActionColumn(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "169.254.169.254", Action: "deny", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", DestinationPorts: "443", NetworkPolicyName: "web"},
+	}
+
+	cases := map[string]struct {
+		rows    []unmarshalcsv.UnmarshalledData
+		wantSub []string
+	}{
+		netpol.FormatCalico: {
+			rows: append(rows, unmarshalcsv.UnmarshalledData{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", Action: "Log", NetworkPolicyName: "web"}),
+			wantSub: []string{
+				"egress:\n  - action: Deny\n    destination:\n      nets:\n      - 169.254.169.254/32",
+				"- action: Allow\n    destination:\n      nets:\n      - 10.0.0.0/8\n      ports:\n      - 443\n    protocol: TCP",
+				"- action: Log",
+			},
+		},
+		netpol.FormatCilium: {
+			rows: rows,
+			wantSub: []string{
+				"egress:\n  - toCIDRSet:\n    - cidr: 10.0.0.0/8",
+				"egressDeny:\n  - toCIDRSet:\n    - cidr: 169.254.169.254/32",
+			},
+		},
+	}
+	for format, tc := range cases {
+		t.Run(format, func(t *testing.T) {
+			outDir := t.TempDir()
+			gp, err := netpol.NewGenericPoliciesWithOptions(tc.rows, outDir, netpol.Options{Format: format})
+			if err != nil {
+				t.Fatalf("build generic policies: %v", err)
+			}
+			if err := gp.RenderGeneric(); err != nil {
+				t.Fatalf("render %s: %v", format, err)
+			}
+			b, err := os.ReadFile(filepath.Join(outDir, "web.yaml"))
+			if err != nil {
+				t.Fatalf("reading rendered file: %v", err)
+			}
+			s := string(b)
+			for _, sub := range tc.wantSub {
+				if !strings.Contains(s, sub) {
+					t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+				}
+			}
+		})
+	}
+
+	rejected := map[string]struct {
+		format, action, wantErr string
+	}{
+		"kubernetes deny": {netpol.FormatKubernetes, "deny", "action Deny cannot be represented by the kubernetes format, which only supports Allow; use --format calico, cilium, anp or istio"},
+		"cilium pass":     {netpol.FormatCilium, "pass", "action Pass cannot be represented by the cilium format, which only supports Allow, Deny; use --format calico or anp"},
+		"anp log":         {netpol.FormatANP, "log", "action Log cannot be represented by the anp format"},
+		"unknown action":  {netpol.FormatCalico, "drop", `unsupported action "drop"`},
+	}
+	for name, tc := range rejected {
+		t.Run(name, func(t *testing.T) {
+			row := rows[0]
+			row.Action = tc.action
+			row.Row = 7
+			_, err := netpol.NewGenericPoliciesWithOptions([]unmarshalcsv.UnmarshalledData{row}, t.TempDir(), netpol.Options{Format: tc.format})
+			if err == nil || !strings.Contains(err.Error(), "row 7 (web)") {
+				t.Fatalf("expected an error pointing at row 7, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
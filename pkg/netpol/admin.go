@@ -0,0 +1,242 @@
+package netpol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceNameLabel is the immutable label carrying the name of every namespace
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// baselineAdminPolicyName is the only name accepted for the BaselineAdminNetworkPolicy singleton
+const baselineAdminPolicyName = "default"
+
+// adminPolicy is a policy.networking.k8s.io/v1alpha1 AdminNetworkPolicy or
+// BaselineAdminNetworkPolicy; both are cluster-scoped
+type adminPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              adminPolicySpec `json:"spec"`
+}
+
+// adminPolicySpec holds the subject and rules; Priority is nil for the baseline policy
+type adminPolicySpec struct {
+	Priority *int        `json:"priority,omitempty"`
+	Subject  adminPeer   `json:"subject"`
+	Ingress  []adminRule `json:"ingress,omitempty"`
+	Egress   []adminRule `json:"egress,omitempty"`
+}
+
+// adminRule is a named rule of an admin policy
+type adminRule struct {
+	Name   string      `json:"name"`
+	Action string      `json:"action"`
+	From   []adminPeer `json:"from,omitempty"`
+	To     []adminPeer `json:"to,omitempty"`
+	Ports  []adminPort `json:"ports,omitempty"`
+}
+
+// adminPeer selects whole namespaces, pods within namespaces, or networks (egress only). The
+// subject of a policy uses the same shape without networks.
+type adminPeer struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *adminPods            `json:"pods,omitempty"`
+	Networks   []string              `json:"networks,omitempty"`
+}
+
+// adminPods selects pods by label within the selected namespaces
+type adminPods struct {
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	PodSelector       metav1.LabelSelector `json:"podSelector"`
+}
+
+// adminPort is exactly one of a port number, a named port or a port range
+type adminPort struct {
+	PortNumber *adminPortNumber `json:"portNumber,omitempty"`
+	NamedPort  string           `json:"namedPort,omitempty"`
+	PortRange  *adminPortRange  `json:"portRange,omitempty"`
+}
+
+// adminPortNumber is a single protocol/port pair
+type adminPortNumber struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+// adminPortRange is an inclusive protocol port range
+type adminPortRange struct {
+	Protocol string `json:"protocol"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// newAdminPolicy converts a GenericPolicy into an AdminNetworkPolicy, or into the
+// BaselineAdminNetworkPolicy when the policy has no priority. The subject namespace (every
+// namespace for cluster-wide policies) and the subject selector select the affected pods.
+func newAdminPolicy(p GenericPolicy) adminPolicy {
+	ap := adminPolicy{
+		TypeMeta:   typeMeta("policy.networking.k8s.io/v1alpha1", "AdminNetworkPolicy"),
+		ObjectMeta: objectMeta(p),
+		Spec:       adminPolicySpec{Priority: p.Priority},
+	}
+	ap.Namespace = ""
+	if p.Priority == nil {
+		ap.Kind = "BaselineAdminNetworkPolicy"
+		ap.Name = baselineAdminPolicyName
+	}
+	if p.PodSelector.IsEmpty() {
+		ap.Spec.Subject = newAdminPeer(p.Namespace, nil)
+	} else {
+		ap.Spec.Subject = newAdminPeer(p.Namespace, &p.PodSelector)
+	}
+	for i, r := range p.Ingress {
+		ap.Spec.Ingress = append(ap.Spec.Ingress, newAdminRule(r, p.Namespace, fmt.Sprintf("ingress-%d", i+1), false))
+	}
+	for i, r := range p.Egress {
+		ap.Spec.Egress = append(ap.Spec.Egress, newAdminRule(r, p.Namespace, fmt.Sprintf("egress-%d", i+1), true))
+	}
+	return ap
+}
+
+// newAdminPeer selects the pods matching podSelector in the namespace (every namespace when
+// empty), or the whole namespace when podSelector is nil
+func newAdminPeer(namespace string, podSelector *LabelSelector) adminPeer {
+	ns := namespaceSelector(namespace).toK8s()
+	if podSelector == nil {
+		return adminPeer{Namespaces: &ns}
+	}
+	return adminPeer{Pods: &adminPods{NamespaceSelector: ns, PodSelector: podSelector.toK8s()}}
+}
+
+// newAdminRule converts a generic rule into an admin rule. Pod peers without namespace select pods
+// in the subject namespace, and a rule without peers matches every namespace (and, for egress,
+// every network).
+func newAdminRule(r GenericRule, namespace, name string, egress bool) adminRule {
+	ar := adminRule{Name: name, Action: r.Action}
+	if ar.Action == "" {
+		ar.Action = ActionAllow
+	}
+	var peers []adminPeer
+	var networks []string
+	for _, peer := range r.Peers {
+		switch {
+		case peer.CIDR != "":
+			networks = append(networks, peer.CIDR)
+		case peer.Namespace != "":
+			peers = append(peers, newAdminPeer(peer.Namespace, peer.PodSelector))
+		default:
+			peers = append(peers, newAdminPeer(namespace, peer.PodSelector))
+		}
+	}
+	if len(networks) > 0 {
+		peers = append(peers, adminPeer{Networks: networks})
+	}
+	if len(peers) == 0 {
+		peers = append(peers, newAdminPeer("", nil))
+		if egress {
+			peers = append(peers, adminPeer{Networks: []string{"0.0.0.0/0", "::/0"}})
+		}
+	}
+	if egress {
+		ar.To = peers
+	} else {
+		ar.From = peers
+	}
+	// Named ports carry no protocol, drop the duplicates created by the protocol cell
+	seen := map[string]bool{}
+	for _, port := range r.Ports {
+		n, _ := strconv.Atoi(port.Port)
+		switch {
+		case port.IsNamed():
+			if !seen[port.Port] {
+				seen[port.Port] = true
+				ar.Ports = append(ar.Ports, adminPort{NamedPort: port.Port})
+			}
+		case port.EndPort != 0:
+			ar.Ports = append(ar.Ports, adminPort{PortRange: &adminPortRange{Protocol: port.Protocol, Start: n, End: port.EndPort}})
+		default:
+			ar.Ports = append(ar.Ports, adminPort{PortNumber: &adminPortNumber{Protocol: port.Protocol, Port: n}})
+		}
+	}
+	return ar
+}
+
+// namespaceSelector selects a namespace by name, or every namespace when name is empty
+func namespaceSelector(name string) LabelSelector {
+	if name == "" {
+		return LabelSelector{}
+	}
+	return LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: name}}
+}
+
+// mergePriority sets the AdminNetworkPolicy priority from the priority cell of a row. Rows merged
+// into one policy must agree on the priority; empty cells leave it unchanged.
+func (p *GenericPolicy) mergePriority(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	priority, err := strconv.Atoi(s)
+	if err != nil || priority < 0 || priority > 1000 {
+		return fmt.Errorf("invalid priority %q: expected a number within 0-1000", s)
+	}
+	if p.Priority != nil && *p.Priority != priority {
+		return fmt.Errorf("conflicting priorities %d and %d", *p.Priority, priority)
+	}
+	p.Priority = &priority
+	return nil
+}
+
+// checkAdminRule rejects peers an AdminNetworkPolicy cannot express: networks are only
+// available as egress peers, without exclusions, and DNS names are not supported.
+func checkAdminRule(r GenericRule, egress bool) error {
+	for _, peer := range r.Peers {
+		switch {
+		case peer.FQDN != "":
+			return fmt.Errorf("hostname %q: AdminNetworkPolicy cannot express FQDN peers", peer.FQDN)
+		case peer.CIDR != "" && !egress:
+			return fmt.Errorf("CIDR %s: AdminNetworkPolicy only supports networks as egress peers", peer.CIDR)
+		case len(peer.Except) > 0:
+			return fmt.Errorf("CIDR %s: AdminNetworkPolicy networks cannot have except blocks", peer.CIDR)
+		}
+	}
+	return nil
+}
+
+// checkAdminPolicies validates the policies rendered as admin policies: they are cluster-scoped,
+// so policies of different namespaces cannot share a name; there is a single
+// BaselineAdminNetworkPolicy, so at most one pod policy may lack a priority, and the baseline
+// policy cannot pass traffic on.
+func checkAdminPolicies(gp []GenericPolicy) error {
+	var baseline string
+	namespaces := map[string]string{}
+	for _, p := range gp {
+		if p.NodeSelector != nil {
+			continue
+		}
+		if p.Priority != nil {
+			ns := p.Namespace
+			if ns == "" {
+				ns = ClusterWideNamespace
+			}
+			if other, ok := namespaces[p.Name]; ok {
+				return fmt.Errorf("policies %s/%s and %s/%s would both be rendered as the cluster-scoped AdminNetworkPolicy %s, rename one of them", other, p.Name, ns, p.Name, p.Name)
+			}
+			namespaces[p.Name] = ns
+			continue
+		}
+		if baseline != "" {
+			return fmt.Errorf("policies %s and %s have no priority, but only one BaselineAdminNetworkPolicy may exist", baseline, p.Name)
+		}
+		baseline = p.Name
+		for _, r := range append(append([]GenericRule{}, p.Ingress...), p.Egress...) {
+			if r.Action == ActionPass {
+				return fmt.Errorf("policy %s: action Pass is not supported by BaselineAdminNetworkPolicy, set a priority", p.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestAdminNetworkPolicyFormat ensures the anp output format renders AdminNetworkPolicies for
+// policies with a priority and the BaselineAdminNetworkPolicy for the one without.
+func TestAdminNetworkPolicyFormat(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "*", SourceSelector: "app", DestinationSpecifier: "169.254.169.254", DestinationPorts: "80", Action: "deny", Priority: "10", NetworkPolicyName: "deny-metadata"},
+		{Direction: "ingress", DestinationNamespace: "*", DestinationSelector: "app", SourceNamespace: "monitoring", SourceSelector: "app=prometheus", DestinationPorts: "metrics,9000-9100", Action: "Allow", Priority: "10", NetworkPolicyName: "deny-metadata"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationNamespace: "ns-b", Action: "deny", NetworkPolicyName: "baseline"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatANP})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render admin policies: %v", err)
+	}
+
+	cases := map[string][]string{
+		"deny-metadata.yaml": {
+			"apiVersion: policy.networking.k8s.io/v1alpha1",
+			"kind: AdminNetworkPolicy",
+			"priority: 10\n  subject:\n    pods:\n      namespaceSelector: {}\n      podSelector:\n        matchExpressions:\n        - key: app\n          operator: Exists",
+			"ingress:\n  - action: Allow\n    from:\n    - pods:\n        namespaceSelector:\n          matchLabels:\n            kubernetes.io/metadata.name: monitoring\n        podSelector:\n          matchLabels:\n            app: prometheus\n    name: ingress-1",
+			"ports:\n    - namedPort: metrics\n    - portRange:\n        end: 9100\n        protocol: TCP\n        start: 9000",
+			"egress:\n  - action: Deny\n    name: egress-1\n    ports:\n    - portNumber:\n        port: 80\n        protocol: TCP\n    to:\n    - networks:\n      - 169.254.169.254/32",
+		},
+		"baseline.yaml": {
+			"kind: BaselineAdminNetworkPolicy",
+			"name: default",
+			"subject:\n    pods:\n      namespaceSelector:\n        matchLabels:\n          kubernetes.io/metadata.name: ns-a",
+			"action: Deny\n    name: egress-1\n    to:\n    - namespaces:\n        matchLabels:\n          kubernetes.io/metadata.name: ns-b",
+		},
+	}
+	for file, wantSubs := range cases {
+		b, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		s := string(b)
+		for _, sub := range wantSubs {
+			if !strings.Contains(s, sub) {
+				t.Fatalf("%s missing substring %q. Content:\n%s", file, sub, s)
+			}
+		}
+	}
+
+	invalid := map[string]unmarshalcsv.UnmarshalledData{
+		"invalid priority":        {Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", Priority: "1001", NetworkPolicyName: "p"},
+		"unsupported action":      {Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", Action: "reject", NetworkPolicyName: "p"},
+		"ingress networks":        {Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=web", SourceSpecifier: "10.0.0.0/8", Priority: "1", NetworkPolicyName: "p"},
+		"pass in baseline":        {Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", Action: "pass", NetworkPolicyName: "p"},
+		"second baseline subject": {Direction: "egress", SourceNamespace: "ns-c", SourceSelector: "app=db", NetworkPolicyName: "other"},
+	}
+	for name, row := range invalid {
+		t.Run(name, func(t *testing.T) {
+			input := []unmarshalcsv.UnmarshalledData{row}
+			if name == "second baseline subject" {
+				input = append(input, rows[2])
+			}
+			if _, err := netpol.NewGenericPoliciesWithOptions(input, t.TempDir(), netpol.Options{Format: netpol.FormatANP}); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+
+	t.Run("same name in two namespaces", func(t *testing.T) {
+		input := []unmarshalcsv.UnmarshalledData{
+			{Direction: "egress", SourceNamespace: "a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", Priority: "10", NetworkPolicyName: "web"},
+			{Direction: "egress", SourceNamespace: "c", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", Priority: "20", NetworkPolicyName: "web"},
+		}
+		if _, err := netpol.NewGenericPoliciesWithOptions(input, t.TempDir(), netpol.Options{Format: netpol.FormatANP}); err == nil || !strings.Contains(err.Error(), "a/web and c/web") {
+			t.Fatalf("expected a name clash, got %v", err)
+		}
+	})
+
+	t.Run("deny requires anp", func(t *testing.T) {
+		if _, err := netpol.NewGenericPoliciesWithOptions(rows[2:], t.TempDir(), netpol.Options{}); err == nil || !strings.Contains(err.Error(), "action Deny") {
+			t.Fatalf("expected action error for kubernetes format, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package netpol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// calicoPolicy is a projectcalico.org/v3 NetworkPolicy or GlobalNetworkPolicy
+type calicoPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              calicoPolicySpec `json:"spec"`
+}
+
+// calicoPolicySpec selects the endpoints in Calico selector syntax
+type calicoPolicySpec struct {
+	Order    *float64 `json:"order,omitempty"`
+	Selector string   `json:"selector"`
+	// NamespaceSelector restricts a GlobalNetworkPolicy to workload endpoints
+	NamespaceSelector string       `json:"namespaceSelector,omitempty"`
+	Types             []string     `json:"types,omitempty"`
+	Ingress           []calicoRule `json:"ingress,omitempty"`
+	Egress            []calicoRule `json:"egress,omitempty"`
+}
+
+// calicoRule is a single Calico rule; Calico rules carry at most one protocol
+// and one namespace selector, so a generic rule may expand into several of them
+type calicoRule struct {
+	Action      string       `json:"action"`
+	Protocol    string       `json:"protocol,omitempty"`
+	Source      calicoEntity `json:"source,omitzero"`
+	Destination calicoEntity `json:"destination,omitzero"`
+}
+
+// calicoEntity is the source or destination match of a Calico rule
+type calicoEntity struct {
+	Nets              []string             `json:"nets,omitempty"`
+	NotNets           []string             `json:"notNets,omitempty"`
+	Domains           []string             `json:"domains,omitempty"` // DNS names and wildcards, egress destinations only
+	NamespaceSelector string               `json:"namespaceSelector,omitempty"`
+	Selector          string               `json:"selector,omitempty"`
+	Ports             []intstr.IntOrString `json:"ports,omitempty"` // numbers, "start:end" ranges or named ports
+}
+
+// IsZero reports whether the entity matches everything, in which case it is omitted
+func (e calicoEntity) IsZero() bool {
+	return len(e.Nets) == 0 && len(e.NotNets) == 0 && len(e.Domains) == 0 && e.Selector == "" && e.NamespaceSelector == "" && len(e.Ports) == 0
+}
+
+// newCalicoPolicy converts a GenericPolicy into a namespaced Calico NetworkPolicy selecting the
+// subject pods. Cluster-wide policies become a GlobalNetworkPolicy applying to pods in every
+// namespace, and node-scoped policies a GlobalNetworkPolicy applying to the host endpoints of the
+// selected nodes (host endpoints inherit the node labels).
+func newCalicoPolicy(p GenericPolicy) calicoPolicy {
+	cp := calicoPolicy{
+		TypeMeta:   typeMeta("projectcalico.org/v3", "NetworkPolicy"),
+		ObjectMeta: objectMeta(p),
+		Spec: calicoPolicySpec{
+			Order:    p.Order,
+			Selector: calicoSelector(p.PodSelector),
+			Types:    p.PolicyTypes(),
+		},
+	}
+	switch {
+	case p.NodeSelector != nil:
+		cp.Kind = "GlobalNetworkPolicy"
+		cp.Namespace = ""
+		cp.Spec.Selector = calicoSelector(*p.NodeSelector)
+	case p.Namespace == "":
+		cp.Kind = "GlobalNetworkPolicy"
+		cp.Spec.NamespaceSelector = "all()"
+	}
+	for _, r := range p.Ingress {
+		cp.Spec.Ingress = append(cp.Spec.Ingress, calicoRules(r, false)...)
+	}
+	for _, r := range p.Egress {
+		cp.Spec.Egress = append(cp.Spec.Egress, calicoRules(r, true)...)
+	}
+	return cp
+}
+
+// mergeOrder sets the Calico order from the order cell of a row. Rows merged into one policy
+// must agree on the order; empty cells leave it unchanged.
+func (p *GenericPolicy) mergeOrder(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	order, err := strconv.ParseFloat(s, 64)
+	if err != nil || order < 0 {
+		return fmt.Errorf("invalid order %q: expected a non-negative number", s)
+	}
+	if p.Order != nil && *p.Order != order {
+		return fmt.Errorf("conflicting orders %v and %v", *p.Order, order)
+	}
+	p.Order = &order
+	return nil
+}
+
+// calicoRules expands a generic rule into Calico rules: one per peer group (plain CIDRs together,
+// DNS names together, each CIDR with exclusions, each in-cluster peer) and per protocol.
+func calicoRules(r GenericRule, egress bool) []calicoRule {
+	var peers []calicoEntity
+	var nets, domains []string
+	for _, peer := range r.Peers {
+		switch {
+		case peer.FQDN != "":
+			domains = append(domains, peer.FQDN)
+		case peer.CIDR != "" && len(peer.Except) == 0:
+			nets = append(nets, peer.CIDR)
+		case peer.CIDR != "":
+			peers = append(peers, calicoEntity{Nets: []string{peer.CIDR}, NotNets: peer.Except})
+		default:
+			e := calicoEntity{}
+			if peer.Namespace != "" {
+				e.NamespaceSelector = fmt.Sprintf("projectcalico.org/name == '%s'", peer.Namespace)
+			}
+			if peer.PodSelector != nil {
+				e.Selector = calicoSelector(*peer.PodSelector)
+			}
+			peers = append(peers, e)
+		}
+	}
+	if len(domains) > 0 {
+		peers = append([]calicoEntity{{Domains: domains}}, peers...)
+	}
+	if len(nets) > 0 {
+		peers = append([]calicoEntity{{Nets: nets}}, peers...)
+	}
+	if len(peers) == 0 {
+		peers = []calicoEntity{{}}
+	}
+
+	var protocols []string
+	ports := map[string][]intstr.IntOrString{}
+	for _, port := range r.Ports {
+		if _, ok := ports[port.Protocol]; !ok {
+			protocols = append(protocols, port.Protocol)
+		}
+		ports[port.Protocol] = append(ports[port.Protocol], calicoPort(port))
+	}
+	if len(protocols) == 0 {
+		protocols = []string{""}
+	}
+
+	action := r.Action
+	if action == "" {
+		action = ActionAllow
+	}
+	var out []calicoRule
+	for _, peer := range peers {
+		for _, proto := range protocols {
+			rule := calicoRule{Action: action, Protocol: proto}
+			if egress {
+				rule.Destination = peer
+				rule.Destination.Ports = ports[proto]
+			} else {
+				rule.Source = peer
+				rule.Destination.Ports = ports[proto]
+			}
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+// calicoPort converts a port to a Calico port: number, "start:end" range or name
+func calicoPort(p GenericPort) intstr.IntOrString {
+	switch {
+	case p.EndPort != 0:
+		return intstr.FromString(fmt.Sprintf("%s:%d", p.Port, p.EndPort))
+	case p.IsNamed():
+		return intstr.FromString(p.Port)
+	default:
+		n, _ := strconv.Atoi(p.Port)
+		return intstr.FromInt32(int32(n))
+	}
+}
@@ -0,0 +1,75 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestCalicoFormat ensures the calico output format renders namespaced Calico NetworkPolicies,
+// and GlobalNetworkPolicies for cluster-wide subjects, with the order taken from the sheet.
+func TestCalicoFormat(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationNamespace: "ns-b", DestinationSelector: "app=backend", DestinationPorts: "8080", Order: "100", NetworkPolicyName: "frontend"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/8!10.96.0.0/12", DestinationProtocol: "UDP", DestinationPorts: "53", NetworkPolicyName: "frontend"},
+		{Direction: "ingress", DestinationNamespace: "*", DestinationSelector: "monitoring in (enabled)", SourceNamespace: "monitoring", SourceSelector: "app=prometheus", DestinationPorts: "metrics", Order: "10.5", NetworkPolicyName: "allow-scrape"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatCalico})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render calico: %v", err)
+	}
+
+	cases := map[string][]string{
+		"frontend.yaml": {
+			"apiVersion: projectcalico.org/v3",
+			"kind: NetworkPolicy",
+			"namespace: ns-a",
+			"order: 100\n  selector: app == 'frontend'\n  types:\n  - Egress",
+			"- action: Allow\n    destination:\n      namespaceSelector: projectcalico.org/name == 'ns-b'\n      ports:\n      - 8080\n      selector: app == 'backend'\n    protocol: TCP",
+			"- action: Allow\n    destination:\n      nets:\n      - 10.0.0.0/8\n      notNets:\n      - 10.96.0.0/12\n      ports:\n      - 53\n    protocol: UDP",
+		},
+		"allow-scrape.yaml": {
+			"kind: GlobalNetworkPolicy",
+			"namespaceSelector: all()\n  order: 10.5\n  selector: monitoring in {'enabled'}",
+			"destination:\n      ports:\n      - metrics\n    protocol: TCP\n    source:\n      namespaceSelector: projectcalico.org/name == 'monitoring'\n      selector: app == 'prometheus'",
+		},
+	}
+	for file, wantSubs := range cases {
+		b, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		s := string(b)
+		for _, sub := range wantSubs {
+			if !strings.Contains(s, sub) {
+				t.Fatalf("%s missing substring %q. Content:\n%s", file, sub, s)
+			}
+		}
+		if file == "allow-scrape.yaml" && strings.Contains(s, "namespace: ") {
+			t.Fatalf("cluster-wide policy must not have a namespace. Content:\n%s", s)
+		}
+	}
+
+	t.Run("conflicting order", func(t *testing.T) {
+		bad := append([]unmarshalcsv.UnmarshalledData{}, rows[:2]...)
+		bad[1].Order = "200"
+		if _, err := netpol.NewGenericPoliciesWithOptions(bad, t.TempDir(), netpol.Options{Format: netpol.FormatCalico}); err == nil || !strings.Contains(err.Error(), "conflicting orders") {
+			t.Fatalf("expected conflicting order error, got %v", err)
+		}
+	})
+
+	t.Run("cluster-wide requires a cluster-scoped backend", func(t *testing.T) {
+		if _, err := netpol.NewGenericPoliciesWithOptions(rows[2:], t.TempDir(), netpol.Options{}); err == nil || !strings.Contains(err.Error(), "cluster-wide subject") {
+			t.Fatalf("expected cluster-wide error for kubernetes format, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package netpol
+
+import "testing"
+
+func TestParseCIDR(t *testing.T) {
+	cases := []struct {
+		in        string
+		canonical bool
+		want      string
+		wantErr   bool
+	}{
+		{in: "10.0.0.5", want: "10.0.0.5/32"},
+		{in: "fd00::1", want: "fd00::1/128"},
+		{in: "10.0.0.0/24", want: "10.0.0.0/24"},
+		{in: "2001:db8::/32", want: "2001:db8::/32"},
+		{in: "::ffff:10.0.0.1", want: "10.0.0.1/32"},
+		{in: "10.0.0.5/24", wantErr: true},
+		{in: "10.0.0.5/24", canonical: true, want: "10.0.0.0/24"},
+		{in: "fd00::1/64", canonical: true, want: "fd00::/64"},
+		{in: "10.0.0.256", wantErr: true},
+		{in: "10.0.0.0/33", wantErr: true},
+		{in: "fe80::1%eth0", wantErr: true},
+		{in: "api.example.com", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseCIDR(tc.in, tc.canonical)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error, got %s", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.in, err)
+		}
+		if got.String() != tc.want {
+			t.Fatalf("%s: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
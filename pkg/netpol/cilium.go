@@ -0,0 +1,207 @@
+package netpol
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ciliumNamespaceLabel is the label Cilium uses to match the namespace of an endpoint
+const ciliumNamespaceLabel = "k8s:io.kubernetes.pod.namespace"
+
+// ciliumPolicy is a cilium.io/v2 CiliumNetworkPolicy or CiliumClusterwideNetworkPolicy
+type ciliumPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ciliumPolicySpec `json:"spec"`
+}
+
+// ciliumPolicySpec selects endpoints (pods) or, for host policies, nodes
+type ciliumPolicySpec struct {
+	EndpointSelector *metav1.LabelSelector `json:"endpointSelector,omitempty"`
+	NodeSelector     *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	Ingress          []ciliumRule          `json:"ingress,omitempty"`
+	IngressDeny      []ciliumRule          `json:"ingressDeny,omitempty"`
+	Egress           []ciliumRule          `json:"egress,omitempty"`
+	EgressDeny       []ciliumRule          `json:"egressDeny,omitempty"`
+}
+
+// ciliumRule is a single Cilium rule selecting peers of exactly one kind
+// (CIDRSet, Endpoints, FQDNs or Entities), optionally restricted to ports.
+// An empty rule matches nothing and only enables default deny for its direction.
+type ciliumRule struct {
+	FromCIDRSet   []ciliumCIDR           `json:"fromCIDRSet,omitempty"`
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromEntities  []string               `json:"fromEntities,omitempty"`
+	ToCIDRSet     []ciliumCIDR           `json:"toCIDRSet,omitempty"`
+	ToEndpoints   []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToFQDNs       []ciliumFQDN           `json:"toFQDNs,omitempty"`
+	ToEntities    []string               `json:"toEntities,omitempty"`
+	ToPorts       []ciliumPortRule       `json:"toPorts,omitempty"`
+}
+
+// ciliumCIDR is a CIDR peer with optional excluded sub-blocks
+type ciliumCIDR struct {
+	CIDR   string   `json:"cidr"`
+	Except []string `json:"except,omitempty"`
+}
+
+// ciliumFQDN matches a DNS name exactly or by wildcard pattern
+type ciliumFQDN struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+// ciliumPortRule restricts a rule to ports, optionally with L7 rules
+type ciliumPortRule struct {
+	Ports []ciliumPort   `json:"ports"`
+	Rules *ciliumL7Rules `json:"rules,omitempty"`
+}
+
+// ciliumPort is a port (always a string in Cilium) or, with EndPort, a port range
+type ciliumPort struct {
+	Port     string `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+	EndPort  int    `json:"endPort,omitempty"`
+}
+
+// ciliumL7Rules are the L7 rules enforced by the Cilium proxy on a port rule
+type ciliumL7Rules struct {
+	DNS []ciliumFQDN `json:"dns,omitempty"`
+}
+
+// newCiliumPolicy converts a GenericPolicy into a CiliumNetworkPolicy selecting the subject pods.
+// Cluster-wide policies become a CiliumClusterwideNetworkPolicy selecting pods in every namespace,
+// and node-scoped policies one applying to the selected nodes (requires the Cilium host firewall).
+func newCiliumPolicy(p GenericPolicy) ciliumPolicy {
+	cp := ciliumPolicy{
+		TypeMeta:   typeMeta("cilium.io/v2", "CiliumNetworkPolicy"),
+		ObjectMeta: objectMeta(p),
+	}
+	switch {
+	case p.NodeSelector != nil:
+		cp.Kind = "CiliumClusterwideNetworkPolicy"
+		cp.Namespace = ""
+		sel := p.NodeSelector.toK8s()
+		cp.Spec.NodeSelector = &sel
+	case p.Namespace == "":
+		cp.Kind = "CiliumClusterwideNetworkPolicy"
+	}
+	if cp.Spec.NodeSelector == nil {
+		sel := p.PodSelector.toK8s()
+		cp.Spec.EndpointSelector = &sel
+	}
+	// Deny rules go to the separate ingressDeny/egressDeny sections, which take precedence
+	for _, r := range p.Ingress {
+		if r.Action == ActionDeny {
+			cp.Spec.IngressDeny = append(cp.Spec.IngressDeny, ciliumRules(r, false)...)
+		} else {
+			cp.Spec.Ingress = append(cp.Spec.Ingress, ciliumRules(r, false)...)
+		}
+	}
+	for _, r := range p.Egress {
+		if r.Action == ActionDeny {
+			cp.Spec.EgressDeny = append(cp.Spec.EgressDeny, ciliumRules(r, true)...)
+		} else {
+			cp.Spec.Egress = append(cp.Spec.Egress, ciliumRules(r, true)...)
+		}
+	}
+	if p.hasFQDN() {
+		cp.Spec.Egress = append(cp.Spec.Egress, ciliumDNSRule())
+	}
+	// A direction without rules (default-deny) is enforced through a single empty rule
+	for _, t := range p.PolicyTypes() {
+		if t == "Ingress" && len(cp.Spec.Ingress) == 0 && len(cp.Spec.IngressDeny) == 0 {
+			cp.Spec.Ingress = []ciliumRule{{}}
+		}
+		if t == "Egress" && len(cp.Spec.Egress) == 0 && len(cp.Spec.EgressDeny) == 0 {
+			cp.Spec.Egress = []ciliumRule{{}}
+		}
+	}
+	return cp
+}
+
+// ciliumRules splits a generic rule into one Cilium rule per peer kind sharing the same ports.
+// A rule without peers matches every peer, which Cilium expresses with the "all" entity.
+func ciliumRules(r GenericRule, egress bool) []ciliumRule {
+	var (
+		cidrs     []ciliumCIDR
+		endpoints []metav1.LabelSelector
+		fqdns     []ciliumFQDN
+	)
+	for _, peer := range r.Peers {
+		switch {
+		case peer.CIDR != "":
+			cidrs = append(cidrs, ciliumCIDR{CIDR: peer.CIDR, Except: peer.Except})
+		case peer.FQDN != "" && peer.IsPattern():
+			fqdns = append(fqdns, ciliumFQDN{MatchPattern: peer.FQDN})
+		case peer.FQDN != "":
+			fqdns = append(fqdns, ciliumFQDN{MatchName: peer.FQDN})
+		default:
+			sel := LabelSelector{MatchLabels: map[string]string{}}
+			if peer.PodSelector != nil {
+				for k, v := range peer.PodSelector.MatchLabels {
+					sel.MatchLabels[k] = v
+				}
+				sel.MatchExpressions = peer.PodSelector.MatchExpressions
+			}
+			if peer.Namespace != "" {
+				sel.MatchLabels[ciliumNamespaceLabel] = peer.Namespace
+			}
+			endpoints = append(endpoints, sel.toK8s())
+		}
+	}
+
+	ports := ciliumPorts(r.Ports)
+	var out []ciliumRule
+	if len(cidrs) > 0 {
+		if egress {
+			out = append(out, ciliumRule{ToCIDRSet: cidrs, ToPorts: ports})
+		} else {
+			out = append(out, ciliumRule{FromCIDRSet: cidrs, ToPorts: ports})
+		}
+	}
+	if len(endpoints) > 0 {
+		if egress {
+			out = append(out, ciliumRule{ToEndpoints: endpoints, ToPorts: ports})
+		} else {
+			out = append(out, ciliumRule{FromEndpoints: endpoints, ToPorts: ports})
+		}
+	}
+	if len(fqdns) > 0 {
+		out = append(out, ciliumRule{ToFQDNs: fqdns, ToPorts: ports})
+	}
+	if len(out) == 0 {
+		if egress {
+			out = append(out, ciliumRule{ToEntities: []string{"all"}, ToPorts: ports})
+		} else {
+			out = append(out, ciliumRule{FromEntities: []string{"all"}, ToPorts: ports})
+		}
+	}
+	return out
+}
+
+// ciliumPorts wraps the ports of a rule into a single port rule, or none when unrestricted
+func ciliumPorts(ports []GenericPort) []ciliumPortRule {
+	if len(ports) == 0 {
+		return nil
+	}
+	rule := ciliumPortRule{}
+	for _, port := range ports {
+		rule.Ports = append(rule.Ports, ciliumPort{Port: port.Port, Protocol: port.Protocol, EndPort: port.EndPort})
+	}
+	return []ciliumPortRule{rule}
+}
+
+// ciliumDNSRule allows DNS lookups through the Cilium DNS proxy to kube-dns. toFQDNs rules only
+// match addresses the proxy has seen resolved, so every policy with FQDN peers carries it.
+func ciliumDNSRule() ciliumRule {
+	return ciliumRule{
+		ToEndpoints: []metav1.LabelSelector{{MatchLabels: map[string]string{
+			ciliumNamespaceLabel: "kube-system",
+			"k8s-app":            "kube-dns",
+		}}},
+		ToPorts: []ciliumPortRule{{
+			Ports: []ciliumPort{{Port: "53", Protocol: "ANY"}},
+			Rules: &ciliumL7Rules{DNS: []ciliumFQDN{{MatchPattern: "*"}}},
+		}},
+	}
+}
@@ -0,0 +1,70 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestCiliumFormat ensures the cilium output format renders CiliumNetworkPolicy objects
+// from the same generic model as the Kubernetes NetworkPolicy backend.
+func TestCiliumFormat(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationNamespace: "ns-b", DestinationSelector: "app=backend", DestinationSpecifier: "10.0.0.0/8!10.96.0.0/12", DestinationPorts: "8000-8100", NetworkPolicyName: "frontend"},
+		{Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=frontend", SourceSpecifier: "10.1.0.0/24", DestinationProtocol: "UDP", DestinationPorts: "53", NetworkPolicyName: "frontend"},
+	}
+
+	outDir := t.TempDir()
+	opts := netpol.Options{Format: netpol.FormatCilium}
+	gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, opts)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render cilium: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "frontend.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	for _, sub := range []string{
+		"apiVersion: cilium.io/v2",
+		"kind: CiliumNetworkPolicy",
+		"namespace: ns-a",
+		"endpointSelector:\n    matchLabels:\n      app: frontend",
+		"- toCIDRSet:\n    - cidr: 10.0.0.0/8\n      except:\n      - 10.96.0.0/12",
+		"- toEndpoints:\n    - matchLabels:\n        app: backend\n        k8s:io.kubernetes.pod.namespace: ns-b",
+		"- endPort: 8100\n        port: \"8000\"\n        protocol: TCP",
+		"- fromCIDRSet:\n    - cidr: 10.1.0.0/24",
+		"- port: \"53\"\n        protocol: UDP",
+	} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+
+	denyDir := t.TempDir()
+	n, err := netpol.NewGenericPoliciesWithOptions(rows, denyDir, opts)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	baseline, err := netpol.NewDefaultDenyPolicies(n)
+	if err != nil {
+		t.Fatalf("build cilium default deny: %v", err)
+	}
+	if err := baseline.RenderGeneric(); err != nil {
+		t.Fatalf("render cilium default deny: %v", err)
+	}
+	b, err = os.ReadFile(filepath.Join(denyDir, "default-deny-ingress.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	if s := string(b); !strings.Contains(s, "endpointSelector: {}\n  ingress:\n  - {}") {
+		t.Fatalf("expected empty ingress rule for default deny. Content:\n%s", s)
+	}
+}
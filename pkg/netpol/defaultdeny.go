@@ -0,0 +1,90 @@
+package netpol
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Names of the default-deny baseline policies
+const (
+	defaultDenyIngress = "default-deny-ingress"
+	defaultDenyEgress  = "default-deny-egress"
+)
+
+// NewDefaultDenyPolicies builds a default-deny-ingress and/or default-deny-egress policy for
+// every namespace of the policies built from the input, for the directions they have rules for.
+// Rows that were skipped or filtered out by Options.Direction therefore never lock a namespace
+// down. The baselines are written to the same output directory, so an error is returned when a
+// policy already uses one of their names, as well as when the format cannot express them.
+// Options.AllowDNS keeps DNS to kube-dns reachable from the default-deny-egress policies.
+func NewDefaultDenyPolicies(policies *NetworkPolicy) (*NetworkPolicy, error) {
+	switch format := policies.opts.effectiveFormat(false); format {
+	case FormatANP:
+		return nil, fmt.Errorf("format %s cannot express a default deny, use a baseline Deny row instead", format)
+	case FormatEgressFirewall, FormatEgressNetworkPolicy:
+		return nil, fmt.Errorf("format %s has one policy per namespace, use Options.DenyAll instead", format)
+	}
+	var namespaces []string
+	seen, ingress, egress := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, p := range policies.generic {
+		if p.Name == defaultDenyIngress || p.Name == defaultDenyEgress {
+			return nil, fmt.Errorf("policy %s/%s would be overwritten by the default-deny baseline of the same name", p.Namespace, p.Name)
+		}
+		if p.Namespace == "" {
+			continue // node-scoped and cluster-wide policies have no subject namespace
+		}
+		if !seen[p.Namespace] {
+			seen[p.Namespace] = true
+			namespaces = append(namespaces, p.Namespace)
+		}
+		for _, t := range p.PolicyTypes() {
+			ingress[p.Namespace] = ingress[p.Namespace] || t == "Ingress"
+			egress[p.Namespace] = egress[p.Namespace] || t == "Egress"
+		}
+	}
+
+	var gp []GenericPolicy
+	for _, ns := range namespaces {
+		if ingress[ns] {
+			gp = append(gp, GenericPolicy{Name: defaultDenyIngress, Namespace: ns, Types: []string{"Ingress"}})
+		}
+		if egress[ns] {
+			if policies.opts.effectiveFormat(false) == FormatIstio {
+				return nil, fmt.Errorf("namespace %s: Istio AuthorizationPolicy only covers ingress, default-deny-egress cannot be rendered", ns)
+			}
+			p := GenericPolicy{Name: defaultDenyEgress, Namespace: ns, Types: []string{"Egress"}}
+			if policies.opts.AllowDNS {
+				p.Egress = []GenericRule{dnsRule()}
+			}
+			gp = append(gp, p)
+		}
+	}
+	baseline := &NetworkPolicy{generic: gp, output: policies.output, opts: policies.opts}
+
+	// Rendered separately, so their file names are checked against each other here
+	files, err := policies.fileNames()
+	if err != nil {
+		return nil, err
+	}
+	baselineFiles, err := baseline.fileNames()
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range baselineFiles {
+		if j := slices.Index(files, file); j >= 0 {
+			return nil, fmt.Errorf("policies %s and %s would both be written to %s, rename the first one", policyKey(policies.generic[j]), policyKey(gp[i]), file)
+		}
+	}
+	return baseline, nil
+}
+
+// dnsRule allows DNS over UDP and TCP to the cluster DNS pods in kube-system
+func dnsRule() GenericRule {
+	return GenericRule{
+		Peers: []GenericPeer{{
+			Namespace:   "kube-system",
+			PodSelector: &LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+		}},
+		Ports: []GenericPort{{Protocol: "UDP", Port: "53"}, {Protocol: "TCP", Port: "53"}},
+	}
+}
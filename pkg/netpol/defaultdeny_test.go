@@ -0,0 +1,117 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestDefaultDenyPolicies ensures a baseline is rendered for every namespace of the built
+// policies and the directions they have rules for, with the optional DNS exception on the egress
+// baseline.
+func TestDefaultDenyPolicies(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend-out"},
+		{Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=frontend", SourceSpecifier: "10.1.0.0/24", DestinationPorts: "443", NetworkPolicyName: "frontend-in"},
+		{Direction: "ingress", DestinationNamespace: "ns-b", DestinationSelector: "app=backend", SourceSpecifier: "10.1.0.0/24", DestinationPorts: "443", NetworkPolicyName: "backend-in"},
+		// Skipped rows must not lock their namespace down
+		{Direction: "egress", SourceNamespace: "ns-c", SourceSelector: "app=batch", DestinationSpecifier: "10.0.0.0/24"},
+		{Direction: "ingress", DestinationNamespace: "ns-d", SourceSpecifier: "10.1.0.0/24", NetworkPolicyName: "db-in"},
+	}
+	build := func(opts netpol.Options) (string, []os.DirEntry) {
+		t.Helper()
+		outDir := t.TempDir()
+		n, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, opts)
+		if err != nil {
+			t.Fatalf("build generic policies: %v", err)
+		}
+		baseline, err := netpol.NewDefaultDenyPolicies(n)
+		if err != nil {
+			t.Fatalf("build default deny: %v", err)
+		}
+		if err := baseline.RenderGeneric(); err != nil {
+			t.Fatalf("render default deny: %v", err)
+		}
+		entries, err := os.ReadDir(outDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return outDir, entries
+	}
+
+	outDir, entries := build(netpol.Options{AllowDNS: true})
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"default-deny-egress.yaml", "ns-a-default-deny-ingress.yaml", "ns-b-default-deny-ingress.yaml"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("rendered %v, want %v", names, want)
+	}
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		b, err := os.ReadFile(filepath.Join(outDir, ns+"-default-deny-ingress.yaml"))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		s := string(b)
+		for _, sub := range []string{"namespace: " + ns, "podSelector: {}", "policyTypes:\n  - Ingress"} {
+			if !strings.Contains(s, sub) {
+				t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+			}
+		}
+		if strings.Contains(s, "ingress:") {
+			t.Fatalf("default deny must not contain ingress rules. Content:\n%s", s)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "default-deny-egress.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	for _, sub := range []string{"namespace: ns-a", "policyTypes:\n  - Egress", "kubernetes.io/metadata.name: kube-system", "k8s-app: kube-dns", "port: 53\n      protocol: UDP"} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+
+	// Only the egress policies are built, so a single egress baseline is expected
+	if _, entries := build(netpol.Options{Direction: "Egress"}); len(entries) != 1 || entries[0].Name() != "default-deny-egress.yaml" {
+		t.Fatalf("expected only default-deny-egress.yaml, got %v", entries)
+	}
+}
+
+// TestDefaultDenyPolicies_NameClash ensures a policy named like a baseline is reported instead
+// of being overwritten.
+func TestDefaultDenyPolicies_NameClash(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/24", NetworkPolicyName: "default-deny-egress"},
+	}
+	n, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if _, err := netpol.NewDefaultDenyPolicies(n); err == nil || !strings.Contains(err.Error(), "ns-a/default-deny-egress would be overwritten") {
+		t.Fatalf("expected a name clash, got %v", err)
+	}
+}
+
+// TestDefaultDenyPolicies_Format ensures formats that cannot express a default deny are reported
+// before anything is rendered.
+func TestDefaultDenyPolicies_Format(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/24", NetworkPolicyName: "web", Priority: "10"},
+	}
+	for _, format := range []string{netpol.FormatANP, netpol.FormatEgressFirewall} {
+		n, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{Format: format})
+		if err != nil {
+			t.Fatalf("%s: build generic policies: %v", format, err)
+		}
+		if _, err := netpol.NewDefaultDenyPolicies(n); err == nil {
+			t.Fatalf("%s: expected an error", format)
+		}
+	}
+}
@@ -25,7 +25,10 @@ func TestEgressGeneratorEndToEnd(t *testing.T) {
 
     // 2) Build generic egress policies and render to a temp dir
     outDir := t.TempDir()
-    gp := netpol.NewGenericPoliciesForDirection(rows, outDir, "Egress")
+    gp, err := netpol.NewGenericPoliciesForDirection(rows, outDir, "Egress")
+    if err != nil {
+        t.Fatalf("build generic egress: %v", err)
+    }
     if err := gp.RenderGeneric(); err != nil {
         t.Fatalf("render generic egress: %v", err)
     }
@@ -0,0 +1,258 @@
+package netpol
+
+import (
+	"circe/pkg/unmarshalcsv"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// egressFirewallName is the only name OVN-Kubernetes accepts for the EgressFirewall of a namespace
+const egressFirewallName = "default"
+
+// egressFirewall is a k8s.ovn.org/v1 EgressFirewall or network.openshift.io/v1 EgressNetworkPolicy
+type egressFirewall struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              egressFirewallSpec `json:"spec"`
+}
+
+// egressFirewallSpec lists the rules, which are evaluated in order
+type egressFirewallSpec struct {
+	Egress []egressFirewallRule `json:"egress"`
+}
+
+// egressFirewallRule matches a single CIDR or DNS name
+type egressFirewallRule struct {
+	Type  string               `json:"type"` // Allow or Deny
+	To    egressFirewallPeer   `json:"to"`
+	Ports []egressFirewallPort `json:"ports,omitempty"`
+}
+
+// egressFirewallPeer is exactly one of a CIDR or a DNS name
+type egressFirewallPeer struct {
+	CIDRSelector string `json:"cidrSelector,omitempty"`
+	DNSName      string `json:"dnsName,omitempty"`
+}
+
+// egressFirewallPort is a numeric protocol/port pair
+type egressFirewallPort struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+// isEgressFirewallFormat reports whether format renders one OpenShift egress firewall per namespace
+func isEgressFirewallFormat(format string) bool {
+	return format == FormatEgressFirewall || format == FormatEgressNetworkPolicy
+}
+
+// newEgressFirewallPolicies aggregates the egress rows of every subject namespace, in sheet
+// order, into a single policy named "default" with one rule per row. Egress firewalls apply to
+// every pod of the namespace, so the subject selector is not used; rows of a namespace with
+// different selectors are reported as warnings (see NetworkPolicy.Warnings). With
+// Options.DenyAll every policy ends with a rule denying the remaining egress traffic. Without
+// Options.Direction the ingress rows are reported as skipped.
+func newEgressFirewallPolicies(input []unmarshalcsv.UnmarshalledData, output string, opts Options) (*NetworkPolicy, error) {
+	format := opts.effectiveFormat(false)
+	if strings.EqualFold(opts.Direction, "ingress") {
+		return nil, fmt.Errorf("format %s only applies to egress traffic", format)
+	}
+	var (
+		gp       []GenericPolicy
+		warnings []Problem
+	)
+	index := map[string]int{}
+	selectors := map[string]string{} // first subject selector of every namespace
+	for row, d := range input {
+		switch {
+		case strings.EqualFold(d.Direction, "ingress"):
+			// Ingress rows are only expected when both directions are requested
+			if opts.Direction == "" {
+				warnings = append(warnings, skippedRow(d, "direction", fmt.Sprintf("format %s only renders egress rows", format)))
+			}
+			continue
+		case !strings.EqualFold(d.Direction, "egress"):
+			if !isBlankRow(d) {
+				warnings = append(warnings, skippedRow(d, "direction", fmt.Sprintf("unsupported direction %q (expected egress or ingress)", d.Direction)))
+			}
+			continue
+		case d.NetworkPolicyName == "":
+			warnings = append(warnings, skippedRow(d, "network_policy_name", "network_policy_name is empty"))
+			continue
+		}
+		if strings.TrimSpace(d.NodeRole) != "" {
+			return nil, rowError(row, d, fmt.Errorf("node_role rows cannot be rendered with format %s", format))
+		}
+		ns := d.SourceNamespace
+		if ns == "" {
+			warnings = append(warnings, subjectSkipped(d))
+			continue
+		}
+		if ns == ClusterWideNamespace {
+			return nil, rowError(row, d, fmt.Errorf("cluster-wide subject (namespace %q) cannot be rendered with format %s", ClusterWideNamespace, format))
+		}
+
+		protocols, err := normalizeProtocols(d.DestinationProtocol)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		ports, err := parsePorts(d.DestinationPorts, protocols)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		action, err := parseAction(d.Action)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if err := checkAction(action, format); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if _, _, err := parseHTTPOperation(d, format); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		rule := GenericRule{Action: action, Ports: ports}
+		rule.Peers, err = buildPeers(d.DestinationSpecifier, d.DestinationNamespace, d.DestinationSelector, opts)
+		if err == nil {
+			err = checkEgressFirewallRule(rule, format)
+		}
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+
+		i, ok := index[ns]
+		if !ok {
+			index[ns] = len(gp)
+			gp = append(gp, GenericPolicy{Name: egressFirewallName, Namespace: ns, Types: []string{"Egress"}})
+			i = len(gp) - 1
+		}
+		// A rule meant for some pods, a deny in particular, applies to the whole namespace; an
+		// empty selector already selects it
+		if sel := strings.TrimSpace(d.SourceSelector); sel != "" {
+			if prev, ok := selectors[ns]; !ok {
+				selectors[ns] = sel
+			} else if sel != prev {
+				msg := fmt.Sprintf("subject selector %q differs from %q used earlier in namespace %s, but the %s applies to every pod of the namespace", sel, prev, ns, format)
+				warnings = append(warnings, Problem{Severity: SeverityWarning, Sheet: d.Sheet, Row: d.Row, Column: "source_selector", Policy: d.NetworkPolicyName, Message: msg})
+			}
+		}
+		if err := gp[i].mergeMetadata(d); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		gp[i].Egress = append(gp[i].Egress, rule)
+	}
+	if opts.DenyAll {
+		for i := range gp {
+			gp[i].Egress = append(gp[i].Egress, GenericRule{Action: ActionDeny})
+		}
+	}
+	return &NetworkPolicy{generic: gp, warnings: warnings, output: output, opts: opts}, nil
+}
+
+// checkEgressFirewallRule rejects what egress firewalls cannot express: they only match
+// destinations outside the cluster, ports must be numeric, and the legacy EgressNetworkPolicy
+// has neither ports nor wildcard DNS names.
+func checkEgressFirewallRule(r GenericRule, format string) error {
+	for _, peer := range r.Peers {
+		if peer.Namespace != "" || peer.PodSelector != nil {
+			return fmt.Errorf("format %s cannot express in-cluster peers, only CIDRs and hostnames", format)
+		}
+		if peer.IsPattern() && format == FormatEgressNetworkPolicy {
+			return fmt.Errorf("hostname %q: EgressNetworkPolicy does not support wildcards", peer.FQDN)
+		}
+	}
+	for _, port := range r.Ports {
+		switch {
+		case format == FormatEgressNetworkPolicy:
+			return fmt.Errorf("EgressNetworkPolicy cannot restrict ports, use --format %s", FormatEgressFirewall)
+		case port.IsNamed():
+			return fmt.Errorf("port %q: EgressFirewall only supports numeric ports", port.Port)
+		case port.EndPort != 0:
+			return fmt.Errorf("port range %s-%d: EgressFirewall does not support port ranges", port.Port, port.EndPort)
+		}
+	}
+	return nil
+}
+
+// newEgressFirewall converts a namespace policy into an EgressFirewall or EgressNetworkPolicy.
+// A CIDR with excluded blocks becomes the CIDRs covering what remains, with the action of the
+// row, so the rule does not match the excluded blocks and later rules still can. A rule without
+// peers matches every IPv4 and IPv6 destination.
+func newEgressFirewall(p GenericPolicy, format string) egressFirewall {
+	ef := egressFirewall{
+		TypeMeta:   typeMeta("k8s.ovn.org/v1", "EgressFirewall"),
+		ObjectMeta: objectMeta(p),
+	}
+	if format == FormatEgressNetworkPolicy {
+		ef.TypeMeta = typeMeta("network.openshift.io/v1", "EgressNetworkPolicy")
+	}
+	for _, r := range p.Egress {
+		action := ActionAllow
+		if r.Action == ActionDeny {
+			action = ActionDeny
+		}
+		var ports []egressFirewallPort
+		for _, port := range r.Ports {
+			n, _ := strconv.Atoi(port.Port)
+			ports = append(ports, egressFirewallPort{Protocol: port.Protocol, Port: n})
+		}
+		add := func(action string, to egressFirewallPeer) {
+			ef.Spec.Egress = append(ef.Spec.Egress, egressFirewallRule{Type: action, To: to, Ports: ports})
+		}
+		peers := r.Peers
+		if len(peers) == 0 {
+			peers = []GenericPeer{{CIDR: "0.0.0.0/0"}, {CIDR: "::/0"}}
+		}
+		for _, peer := range peers {
+			if peer.FQDN != "" {
+				add(action, egressFirewallPeer{DNSName: peer.FQDN})
+				continue
+			}
+			for _, cidr := range subtractCIDRs(peer.CIDR, peer.Except) {
+				add(action, egressFirewallPeer{CIDRSelector: cidr})
+			}
+		}
+	}
+	return ef
+}
+
+// subtractCIDRs returns the smallest set of CIDRs covering cidr without the excluded blocks, in
+// address order. Both are canonical, as built by buildPeers.
+func subtractCIDRs(cidr string, except []string) []string {
+	parent, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return []string{cidr}
+	}
+	var excluded []netip.Prefix
+	for _, e := range except {
+		if prefix, err := netip.ParsePrefix(e); err == nil {
+			excluded = append(excluded, prefix)
+		}
+	}
+	var out []string
+	var split func(p netip.Prefix)
+	split = func(p netip.Prefix) {
+		overlaps := false
+		for _, e := range excluded {
+			if e.Bits() <= p.Bits() && e.Contains(p.Addr()) {
+				return // fully excluded
+			}
+			overlaps = overlaps || p.Contains(e.Addr())
+		}
+		if !overlaps {
+			out = append(out, p.String())
+			return
+		}
+		// Split into the two halves, the upper one having the next bit set
+		low := netip.PrefixFrom(p.Addr(), p.Bits()+1)
+		b := p.Addr().AsSlice()
+		b[p.Bits()/8] |= 0x80 >> (p.Bits() % 8)
+		high, _ := netip.AddrFromSlice(b)
+		split(low)
+		split(netip.PrefixFrom(high, p.Bits()+1))
+	}
+	split(parent)
+	return out
+}
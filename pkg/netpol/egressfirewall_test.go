@@ -0,0 +1,210 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestEgressFirewallFormat ensures the egress rows of a namespace are aggregated, in sheet order,
+// into a single EgressFirewall named "default", excluded blocks being left out of the CIDRs.
+func TestEgressFirewallFormat(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "api.partner.com", DestinationPorts: "443", Comment: "partner api", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=batch", DestinationSpecifier: "10.0.0.0/8!10.96.0.0/12", DestinationProtocol: "TCP,UDP", DestinationPorts: "53", NetworkPolicyName: "batch"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "169.254.169.254", Action: "deny", NetworkPolicyName: "web"},
+		{Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=web", SourceSpecifier: "10.1.0.0/24", NetworkPolicyName: "web"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatEgressFirewall, DenyAll: true})
+	if err != nil {
+		t.Fatalf("build egress firewalls: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render egress firewalls: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "default.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	want := `spec:
+  egress:
+  - ports:
+    - port: 443
+      protocol: TCP
+    to:
+      dnsName: api.partner.com
+    type: Allow
+  - ports:
+    - port: 53
+      protocol: TCP
+    - port: 53
+      protocol: UDP
+    to:
+      cidrSelector: 10.0.0.0/10
+    type: Allow
+  - ports:
+    - port: 53
+      protocol: TCP
+    - port: 53
+      protocol: UDP
+    to:
+      cidrSelector: 10.64.0.0/11
+    type: Allow
+  - ports:
+    - port: 53
+      protocol: TCP
+    - port: 53
+      protocol: UDP
+    to:
+      cidrSelector: 10.112.0.0/12
+    type: Allow
+  - ports:
+    - port: 53
+      protocol: TCP
+    - port: 53
+      protocol: UDP
+    to:
+      cidrSelector: 10.128.0.0/9
+    type: Allow
+  - to:
+      cidrSelector: 169.254.169.254/32
+    type: Deny
+  - to:
+      cidrSelector: 0.0.0.0/0
+    type: Deny
+  - to:
+      cidrSelector: ::/0
+    type: Deny`
+	for _, sub := range []string{"apiVersion: k8s.ovn.org/v1", "kind: EgressFirewall", "name: default", "namespace: ns-a", "circe/comment: partner api", want} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+
+	invalid := map[string]struct {
+		format string
+		row    unmarshalcsv.UnmarshalledData
+	}{
+		"in-cluster peer":        {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{DestinationNamespace: "ns-b"}},
+		"named port":             {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.0/8", DestinationPorts: "http"}},
+		"port range":             {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.0/8", DestinationPorts: "8000-8100"}},
+		"pass action":            {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.0/8", Action: "pass"}},
+		"legacy ports":           {netpol.FormatEgressNetworkPolicy, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.0/8", DestinationPorts: "443"}},
+		"legacy wildcard":        {netpol.FormatEgressNetworkPolicy, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "*.example.com"}},
+		"cluster-wide namespace": {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{SourceNamespace: "*", DestinationSpecifier: "10.0.0.0/8"}},
+		"node-scoped row":        {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{NodeRole: "worker", DestinationSpecifier: "10.0.0.0/8"}},
+		"unparseable specifier":  {netpol.FormatEgressFirewall, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.300"}},
+		"legacy log action":      {netpol.FormatEgressNetworkPolicy, unmarshalcsv.UnmarshalledData{DestinationSpecifier: "10.0.0.0/8", Action: "log"}},
+	}
+	for name, tc := range invalid {
+		t.Run(name, func(t *testing.T) {
+			row := tc.row
+			row.Direction, row.SourceSelector, row.NetworkPolicyName = "egress", "app=web", "web"
+			if row.SourceNamespace == "" {
+				row.SourceNamespace = "ns-a"
+			}
+			if _, err := netpol.NewGenericPoliciesWithOptions([]unmarshalcsv.UnmarshalledData{row}, t.TempDir(), netpol.Options{Format: tc.format}); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+
+	t.Run("egress network policy", func(t *testing.T) {
+		outDir := t.TempDir()
+		gp, err := netpol.NewGenericPoliciesWithOptions(rows[2:3], outDir, netpol.Options{Format: netpol.FormatEgressNetworkPolicy})
+		if err != nil {
+			t.Fatalf("build egress network policies: %v", err)
+		}
+		if err := gp.RenderGeneric(); err != nil {
+			t.Fatalf("render egress network policies: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(outDir, "default.yaml"))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		if s := string(b); !strings.Contains(s, "apiVersion: network.openshift.io/v1\nkind: EgressNetworkPolicy") {
+			t.Fatalf("expected an EgressNetworkPolicy. Content:\n%s", s)
+		}
+	})
+}
+
+// TestEgressFirewallSelectors ensures rows of one namespace with different subject selectors are
+// reported, as the egress firewall applies their rules to every pod of the namespace.
+func TestEgressFirewallSelectors(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Row: 2, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Row: 3, Direction: "egress", SourceNamespace: "ns-a", DestinationSpecifier: "10.1.0.0/16", NetworkPolicyName: "all"},
+		{Row: 4, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=db", DestinationSpecifier: "0.0.0.0/0", Action: "deny", NetworkPolicyName: "db"},
+		{Row: 5, Direction: "egress", SourceNamespace: "ns-b", SourceSelector: "app=db", DestinationSpecifier: "0.0.0.0/0", NetworkPolicyName: "db"},
+	}
+	n, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{Format: netpol.FormatEgressFirewall})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	warnings := n.Warnings()
+	if len(warnings) != 1 || len(n.Skipped()) != 0 {
+		t.Fatalf("expected a single warning and no skipped row, got %v", warnings)
+	}
+	if w := warnings[0]; w.Severity != netpol.SeverityWarning || w.Row != 4 || w.Column != "source_selector" || !strings.Contains(w.Message, `"app=db" differs from "app=web"`) {
+		t.Fatalf("unexpected warning: %+v", w)
+	}
+	if problems := netpol.Validate(rows, netpol.Options{Format: netpol.FormatEgressFirewall}); len(problems) != 1 || problems[0] != warnings[0] {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+// TestEgressFirewallExcept ensures excluded blocks only stop a rule from matching: they neither
+// allow what a deny row excludes nor shadow the rows that follow.
+func TestEgressFirewallExcept(t *testing.T) {
+	render := func(rows []unmarshalcsv.UnmarshalledData) string {
+		t.Helper()
+		outDir := t.TempDir()
+		gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatEgressFirewall, DenyAll: true})
+		if err != nil {
+			t.Fatalf("build egress firewalls: %v", err)
+		}
+		if err := gp.RenderGeneric(); err != nil {
+			t.Fatalf("render egress firewalls: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(outDir, "default.yaml"))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		return string(b)
+	}
+
+	t.Run("deny with except", func(t *testing.T) {
+		s := render([]unmarshalcsv.UnmarshalledData{
+			{Direction: "egress", SourceNamespace: "ns-a", DestinationSpecifier: "10.0.0.0/8!10.1.0.0/16", DestinationPorts: "443", Action: "deny", NetworkPolicyName: "web"},
+		})
+		if strings.Count(s, "type: Allow") != 0 {
+			t.Fatalf("a deny row must not allow its excluded blocks. Content:\n%s", s)
+		}
+		for _, cidr := range []string{"10.0.0.0/16", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9"} {
+			if !strings.Contains(s, "cidrSelector: "+cidr+"\n    type: Deny") {
+				t.Fatalf("missing deny rule for %s. Content:\n%s", cidr, s)
+			}
+		}
+		if strings.Contains(s, "10.1.0.0/16") {
+			t.Fatalf("the excluded block must not be matched. Content:\n%s", s)
+		}
+	})
+
+	t.Run("allow with except", func(t *testing.T) {
+		s := render([]unmarshalcsv.UnmarshalledData{
+			{Direction: "egress", SourceNamespace: "ns-a", DestinationSpecifier: "10.0.0.0/8!10.96.0.0/12", NetworkPolicyName: "web"},
+			{Direction: "egress", SourceNamespace: "ns-a", DestinationSpecifier: "10.96.0.10", NetworkPolicyName: "dns"},
+		})
+		// Only the trailing deny-all rules may deny, so the second row is reachable
+		if strings.Count(s, "type: Deny") != 2 || !strings.Contains(s, "cidrSelector: 10.96.0.10/32\n    type: Allow") {
+			t.Fatalf("the excluded block must not shadow later rows. Content:\n%s", s)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package netpol
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// fqdnLabelRe matches a single DNS label in which "*" may stand for any characters
+var fqdnLabelRe = regexp.MustCompile(`^[a-z0-9*]([-a-z0-9*]{0,61}[a-z0-9*])?$`)
+
+// parseFQDN recognises a hostname ("api.partner.com") or a wildcard pattern
+// ("*.s3.amazonaws.com") in a specifier cell. Names are lower-cased and a trailing dot is
+// dropped. The top-level label must contain a letter, so malformed IPs ("10.0.0.256") are not
+// mistaken for hostnames.
+func parseFQDN(s string) (string, bool) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+	if len(name) == 0 || len(name) > 253 {
+		return "", false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return "", false
+	}
+	for _, l := range labels {
+		if !fqdnLabelRe.MatchString(l) {
+			return "", false
+		}
+	}
+	tld := labels[len(labels)-1]
+	if strings.Contains(tld, "*") || !strings.ContainsAny(tld, "abcdefghijklmnopqrstuvwxyz") {
+		return "", false
+	}
+	return name, true
+}
+
+// IsPattern reports whether the FQDN peer is a wildcard pattern rather than an exact name
+func (p GenericPeer) IsPattern() bool {
+	return strings.Contains(p.FQDN, "*")
+}
+
+// hasFQDN reports whether any rule of the policy selects peers by DNS name
+func (p GenericPolicy) hasFQDN() bool {
+	for _, rules := range [][]GenericRule{p.Ingress, p.Egress} {
+		for _, r := range rules {
+			for _, peer := range r.Peers {
+				if peer.FQDN != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// checkFQDNPeers rejects FQDN peers the selected backend cannot express: DNS names only make
+// sense as egress destinations, a plain Kubernetes NetworkPolicy has no notion of them and
+// Cilium only matches them in allow rules.
+func checkFQDNPeers(r GenericRule, egress, nodeScoped bool, opts Options) error {
+	for _, peer := range r.Peers {
+		if peer.FQDN == "" {
+			continue
+		}
+		if !egress {
+			return fmt.Errorf("hostname %q: FQDN peers are only supported for egress", peer.FQDN)
+		}
+		if opts.effectiveFormat(nodeScoped) == FormatKubernetes {
+			return fmt.Errorf("hostname %q: Kubernetes NetworkPolicy cannot express FQDN peers, use --format cilium or calico", peer.FQDN)
+		}
+		if r.Action == ActionDeny && opts.effectiveFormat(nodeScoped) == FormatCilium {
+			return fmt.Errorf("hostname %q: Cilium deny rules cannot match FQDN peers", peer.FQDN)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestFQDNPeers ensures hostnames and wildcards in the specifier cell become FQDN peers
+// instead of being parsed as IP addresses.
+func TestFQDNPeers(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "api.partner.com, *.s3.amazonaws.com, 10.0.0.0/8", DestinationPorts: "443", NetworkPolicyName: "web"},
+	}
+
+	t.Run("cilium", func(t *testing.T) {
+		outDir := t.TempDir()
+		gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatCilium})
+		if err != nil {
+			t.Fatalf("build generic policies: %v", err)
+		}
+		if err := gp.RenderGeneric(); err != nil {
+			t.Fatalf("render cilium: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(outDir, "web.yaml"))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		s := string(b)
+		for _, sub := range []string{
+			"- toCIDRSet:\n    - cidr: 10.0.0.0/8",
+			"- toFQDNs:\n    - matchName: api.partner.com\n    - matchPattern: '*.s3.amazonaws.com'\n    toPorts:\n    - ports:\n      - port: \"443\"",
+			"k8s-app: kube-dns",
+			"protocol: ANY\n      rules:\n        dns:\n        - matchPattern: '*'",
+		} {
+			if !strings.Contains(s, sub) {
+				t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+			}
+		}
+	})
+
+	t.Run("calico node policy", func(t *testing.T) {
+		nodeRows := []unmarshalcsv.UnmarshalledData{
+			{Direction: "egress", NodeRole: "worker", DestinationSpecifier: "Registry.Example.com.", DestinationPorts: "443", NetworkPolicyName: "registry"},
+		}
+		outDir := t.TempDir()
+		gp, err := netpol.NewGenericPoliciesWithOptions(nodeRows, outDir, netpol.Options{})
+		if err != nil {
+			t.Fatalf("build generic policies: %v", err)
+		}
+		if err := gp.RenderGeneric(); err != nil {
+			t.Fatalf("render calico: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(outDir, "registry.yaml"))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		if s := string(b); !strings.Contains(s, "destination:\n      domains:\n      - registry.example.com") {
+			t.Fatalf("expected calico destination domains. Content:\n%s", s)
+		}
+	})
+
+	t.Run("kubernetes rejects FQDN", func(t *testing.T) {
+		_, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{})
+		if err == nil || !strings.Contains(err.Error(), "cannot express FQDN peers") {
+			t.Fatalf("expected FQDN error for kubernetes format, got %v", err)
+		}
+	})
+
+	t.Run("invalid peers", func(t *testing.T) {
+		for _, spec := range []string{"10.0.0.256", "api.partner.com!10.0.0.0/8", "localhost"} {
+			bad := []unmarshalcsv.UnmarshalledData{
+				{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: spec, NetworkPolicyName: "web"},
+			}
+			if _, err := netpol.NewGenericPoliciesWithOptions(bad, t.TempDir(), netpol.Options{Format: netpol.FormatCilium}); err == nil {
+				t.Fatalf("expected error for specifier %q", spec)
+			}
+		}
+		ingress := []unmarshalcsv.UnmarshalledData{
+			{Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=web", SourceSpecifier: "api.partner.com", NetworkPolicyName: "web"},
+		}
+		if _, err := netpol.NewGenericPoliciesWithOptions(ingress, t.TempDir(), netpol.Options{Format: netpol.FormatCilium}); err == nil || !strings.Contains(err.Error(), "only supported for egress") {
+			t.Fatalf("expected egress-only error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestGroupRowsByPolicyName ensures rows sharing network_policy_name and namespace
+// render into a single policy containing one rule per row.
+func TestGroupRowsByPolicyName(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/24", DestinationProtocol: "TCP", DestinationPorts: "80", NetworkPolicyName: "frontend-out"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.1.0/24", DestinationProtocol: "UDP", DestinationPorts: "53", NetworkPolicyName: "frontend-out"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outDir, "frontend-out.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	if n := strings.Count(s, "  - ports:"); n != 2 {
+		t.Fatalf("expected 2 egress rules, got %d. Content:\n%s", n, s)
+	}
+	for _, sub := range []string{"cidr: 10.0.0.0/24", "cidr: 10.0.1.0/24", "protocol: UDP", "port: 53"} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+}
+
+// TestGroupRowsConflictingSelectors ensures rows for the same policy cannot disagree on the subject.
+func TestGroupRowsConflictingSelectors(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend-out"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=backend", DestinationSpecifier: "10.0.1.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend-out"},
+	}
+	if _, err := netpol.NewGenericPolicies(rows, t.TempDir()); err == nil {
+		t.Fatalf("expected conflicting selector error")
+	}
+}
+
+// TestGroupRowsBothDirections ensures an egress and an ingress row for the same policy
+// render into a single NetworkPolicy carrying both policy types and rule sections.
+func TestGroupRowsBothDirections(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend"},
+		{Direction: "ingress", DestinationNamespace: "ns-a", DestinationSelector: "app=frontend", SourceSpecifier: "10.1.0.0/24", DestinationPorts: "443", NetworkPolicyName: "frontend"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outDir, "frontend.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	for _, sub := range []string{"- Ingress\n  - Egress", "ingress:\n  - from:", "cidr: 10.1.0.0/24", "egress:\n  - ports:", "cidr: 10.0.0.0/24"} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+}
+
+// TestFileNameClash ensures a policy whose name matches the file name given to a policy of the
+// same name in several namespaces is reported instead of being overwritten.
+func TestFileNameClash(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "c", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "b", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "a-web"},
+	}
+	if _, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{}); err == nil || !strings.Contains(err.Error(), "a/web and b/a-web would both be written to a-web.yaml") {
+		t.Fatalf("expected a file name clash, got %v", err)
+	}
+
+	// The default-deny baselines are rendered separately and checked against the policies
+	rows = []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "b", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Direction: "egress", SourceNamespace: "c", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "a-default-deny-egress"},
+	}
+	n, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if _, err := netpol.NewDefaultDenyPolicies(n); err == nil || !strings.Contains(err.Error(), "a-default-deny-egress.yaml") {
+		t.Fatalf("expected a file name clash, got %v", err)
+	}
+}
@@ -25,7 +25,10 @@ func TestIngressGeneratorEndToEnd(t *testing.T) {
 
     // 2) Build generic ingress policies and render to a temp dir
     outDir := t.TempDir()
-    gp := netpol.NewGenericPoliciesForDirection(rows, outDir, "Ingress")
+    gp, err := netpol.NewGenericPoliciesForDirection(rows, outDir, "Ingress")
+    if err != nil {
+        t.Fatalf("build generic ingress: %v", err)
+    }
     if err := gp.RenderGeneric(); err != nil {
         t.Fatalf("render generic ingress: %v", err)
     }
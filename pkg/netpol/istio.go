@@ -0,0 +1,194 @@
+package netpol
+
+import (
+	"circe/pkg/unmarshalcsv"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// istioActions maps the rule actions to the AuthorizationPolicy actions
+var istioActions = map[string]string{
+	ActionAllow: "ALLOW",
+	ActionDeny:  "DENY",
+	ActionLog:   "AUDIT",
+}
+
+// httpMethodRe matches an HTTP method token such as GET or PROPFIND
+var httpMethodRe = regexp.MustCompile(`^[A-Z]+$`)
+
+// istioPolicy is a security.istio.io/v1 AuthorizationPolicy
+type istioPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              istioPolicySpec `json:"spec"`
+}
+
+// istioPolicySpec applies one action to the requests matching any of the rules; without a
+// selector it applies to every workload of the namespace
+type istioPolicySpec struct {
+	Selector *istioSelector `json:"selector,omitempty"`
+	Action   string         `json:"action"` // ALLOW, DENY or AUDIT
+	Rules    []istioRule    `json:"rules,omitempty"`
+}
+
+// istioSelector selects workloads by equality labels
+type istioSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// istioRule matches requests from any of From to any of To; an empty rule matches every request
+type istioRule struct {
+	From []istioFrom `json:"from,omitempty"`
+	To   []istioTo   `json:"to,omitempty"`
+}
+
+// istioFrom wraps a request source
+type istioFrom struct {
+	Source istioSource `json:"source"`
+}
+
+// istioSource is a single from.source entry; its fields are ANDed
+type istioSource struct {
+	IPBlocks    []string `json:"ipBlocks,omitempty"`
+	NotIPBlocks []string `json:"notIpBlocks,omitempty"`
+	Namespaces  []string `json:"namespaces,omitempty"`
+}
+
+// istioTo wraps a request operation
+type istioTo struct {
+	Operation istioOperation `json:"operation"`
+}
+
+// istioOperation matches the destination port and HTTP method and path of a request
+type istioOperation struct {
+	Ports   []string `json:"ports,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+}
+
+// parseHTTPOperation parses the http_methods and http_paths cells of a row. Only the istio
+// format can match HTTP requests; other formats reject the cells rather than silently widening
+// the rule to every request.
+func parseHTTPOperation(d unmarshalcsv.UnmarshalledData, format string) ([]string, []string, error) {
+	methods, paths := splitAndTrim(d.HTTPMethods), splitAndTrim(d.HTTPPaths)
+	if len(methods) == 0 && len(paths) == 0 {
+		return nil, nil, nil
+	}
+	if format != FormatIstio {
+		return nil, nil, fmt.Errorf("http_methods and http_paths require --format istio")
+	}
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(m)
+		if !httpMethodRe.MatchString(methods[i]) {
+			return nil, nil, fmt.Errorf("invalid HTTP method %q", m)
+		}
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "*") {
+			return nil, nil, fmt.Errorf("invalid HTTP path %q: expected an absolute path, a prefix (/api/*) or a suffix (*/info)", path)
+		}
+	}
+	return methods, paths, nil
+}
+
+// checkIstioRule rejects what an AuthorizationPolicy cannot express: it only controls requests
+// received by the subject pods, selects workloads with equality labels, identifies peers by
+// address or namespace, and matches TCP ports by number.
+func checkIstioRule(p GenericPolicy, r GenericRule, egress bool) error {
+	if egress {
+		return fmt.Errorf("Istio AuthorizationPolicy only applies to ingress rows")
+	}
+	if len(p.PodSelector.MatchExpressions) > 0 {
+		return fmt.Errorf("selector %q: Istio workload selectors only support equality requirements", p.Selector)
+	}
+	for _, peer := range r.Peers {
+		if peer.PodSelector != nil {
+			return fmt.Errorf("Istio AuthorizationPolicy cannot select peer pods by label, use source_namespace or source_specifier")
+		}
+	}
+	for _, port := range r.Ports {
+		switch {
+		case port.Protocol != "TCP":
+			return fmt.Errorf("port %s/%s: Istio AuthorizationPolicy only matches TCP ports", port.Protocol, port.Port)
+		case port.IsNamed():
+			return fmt.Errorf("port %q: Istio AuthorizationPolicy only supports numeric ports", port.Port)
+		case port.EndPort != 0:
+			return fmt.Errorf("port range %s-%d: Istio AuthorizationPolicy does not support port ranges", port.Port, port.EndPort)
+		}
+	}
+	return nil
+}
+
+// checkIstioPolicies validates the policies rendered as AuthorizationPolicies, whose action
+// applies to every rule: the rows merged into one policy must share the same action.
+func checkIstioPolicies(gp []GenericPolicy) error {
+	for _, p := range gp {
+		if p.NodeSelector != nil {
+			continue
+		}
+		for _, r := range p.Ingress[1:] {
+			if r.Action != p.Ingress[0].Action {
+				return fmt.Errorf("policy %s/%s: rows with actions %s and %s cannot share one AuthorizationPolicy", p.Namespace, p.Name, p.Ingress[0].Action, r.Action)
+			}
+		}
+	}
+	return nil
+}
+
+// newIstioPolicy converts an ingress GenericPolicy into an AuthorizationPolicy. A policy without
+// rules (default deny) renders as an ALLOW policy matching nothing, which denies every request.
+func newIstioPolicy(p GenericPolicy) istioPolicy {
+	ip := istioPolicy{
+		TypeMeta:   typeMeta("security.istio.io/v1", "AuthorizationPolicy"),
+		ObjectMeta: objectMeta(p),
+		Spec:       istioPolicySpec{Action: istioActions[ActionAllow]},
+	}
+	if len(p.PodSelector.MatchLabels) > 0 {
+		ip.Spec.Selector = &istioSelector{MatchLabels: p.PodSelector.MatchLabels}
+	}
+	if len(p.Ingress) > 0 && p.Ingress[0].Action != "" {
+		ip.Spec.Action = istioActions[p.Ingress[0].Action]
+	}
+	for _, r := range p.Ingress {
+		ip.Spec.Rules = append(ip.Spec.Rules, newIstioRule(r))
+	}
+	return ip
+}
+
+// newIstioRule groups the peers of a rule into sources: plain CIDRs together, each CIDR with
+// exclusions on its own and all namespaces together.
+func newIstioRule(r GenericRule) istioRule {
+	var ir istioRule
+	var ipBlocks, namespaces []string
+	for _, peer := range r.Peers {
+		switch {
+		case peer.CIDR != "" && len(peer.Except) == 0:
+			ipBlocks = append(ipBlocks, peer.CIDR)
+		case peer.CIDR != "":
+			ir.From = append(ir.From, istioFrom{Source: istioSource{IPBlocks: []string{peer.CIDR}, NotIPBlocks: peer.Except}})
+		case peer.Namespace != "":
+			namespaces = append(namespaces, peer.Namespace)
+		}
+	}
+	if len(namespaces) > 0 {
+		ir.From = append([]istioFrom{{Source: istioSource{Namespaces: namespaces}}}, ir.From...)
+	}
+	if len(ipBlocks) > 0 {
+		ir.From = append([]istioFrom{{Source: istioSource{IPBlocks: ipBlocks}}}, ir.From...)
+	}
+	op := istioOperation{Methods: r.Methods, Paths: r.Paths}
+	seen := map[string]bool{}
+	for _, port := range r.Ports {
+		if !seen[port.Port] {
+			seen[port.Port] = true
+			op.Ports = append(op.Ports, port.Port)
+		}
+	}
+	if len(op.Ports) > 0 || len(op.Methods) > 0 || len(op.Paths) > 0 {
+		ir.To = []istioTo{{Operation: op}}
+	}
+	return ir
+}
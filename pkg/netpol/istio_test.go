@@ -0,0 +1,118 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestIstioFormat ensures ingress rows render as Istio AuthorizationPolicies, including the
+// optional HTTP method and path columns.
+func TestIstioFormat(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", SourceNamespace: "frontend,gateway", DestinationPorts: "8080", HTTPMethods: "get,POST", HTTPPaths: "/api/*", NetworkPolicyName: "api"},
+		{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", SourceSpecifier: "10.0.0.0/8!10.96.0.0/12, 192.168.0.0/16", NetworkPolicyName: "api"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{Format: netpol.FormatIstio})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render istio: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "api.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	want := `spec:
+  action: ALLOW
+  rules:
+  - from:
+    - source:
+        namespaces:
+        - frontend
+        - gateway
+    to:
+    - operation:
+        methods:
+        - GET
+        - POST
+        paths:
+        - /api/*
+        ports:
+        - "8080"
+  - from:
+    - source:
+        ipBlocks:
+        - 192.168.0.0/16
+    - source:
+        ipBlocks:
+        - 10.0.0.0/8
+        notIpBlocks:
+        - 10.96.0.0/12
+  selector:
+    matchLabels:
+      app: api`
+	for _, sub := range []string{"apiVersion: security.istio.io/v1", "kind: AuthorizationPolicy", "namespace: shop", want} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+
+	denyDir := t.TempDir()
+	n, err := netpol.NewGenericPoliciesWithOptions(rows, denyDir, netpol.Options{Direction: "Ingress", Format: netpol.FormatIstio})
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	baseline, err := netpol.NewDefaultDenyPolicies(n)
+	if err != nil {
+		t.Fatalf("build istio default deny: %v", err)
+	}
+	if err := baseline.RenderGeneric(); err != nil {
+		t.Fatalf("render istio default deny: %v", err)
+	}
+	b, err = os.ReadFile(filepath.Join(denyDir, "default-deny-ingress.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	if s := string(b); !strings.HasSuffix(strings.TrimSpace(s), "spec:\n  action: ALLOW") {
+		t.Fatalf("expected an ALLOW policy without rules for default deny. Content:\n%s", s)
+	}
+
+	invalid := map[string]struct {
+		format string
+		row    unmarshalcsv.UnmarshalledData
+	}{
+		"egress row":           {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "egress", SourceNamespace: "shop", SourceSelector: "app=api"}},
+		"set-based selector":   {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app in (api)"}},
+		"peer pod selector":    {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", SourceSelector: "app=web"}},
+		"udp port":             {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", DestinationProtocol: "UDP", DestinationPorts: "53"}},
+		"invalid path":         {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", HTTPPaths: "api"}},
+		"methods without L7":   {netpol.FormatCilium, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "shop", DestinationSelector: "app=api", HTTPMethods: "GET"}},
+		"cluster-wide subject": {netpol.FormatIstio, unmarshalcsv.UnmarshalledData{Direction: "ingress", DestinationNamespace: "*", DestinationSelector: "app=api"}},
+	}
+	for name, tc := range invalid {
+		t.Run(name, func(t *testing.T) {
+			row := tc.row
+			row.NetworkPolicyName = "api"
+			if _, err := netpol.NewGenericPoliciesWithOptions([]unmarshalcsv.UnmarshalledData{row}, t.TempDir(), netpol.Options{Format: tc.format}); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+
+	t.Run("mixed actions", func(t *testing.T) {
+		mixed := append([]unmarshalcsv.UnmarshalledData{}, rows...)
+		mixed[1].Action = "deny"
+		if _, err := netpol.NewGenericPoliciesWithOptions(mixed, t.TempDir(), netpol.Options{Format: netpol.FormatIstio}); err == nil || !strings.Contains(err.Error(), "cannot share one AuthorizationPolicy") {
+			t.Fatalf("expected mixed action error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package netpol
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newNetworkPolicy converts a GenericPolicy into a networking.k8s.io/v1 NetworkPolicy
+func newNetworkPolicy(p GenericPolicy) networkingv1.NetworkPolicy {
+	np := networkingv1.NetworkPolicy{
+		TypeMeta:   typeMeta("networking.k8s.io/v1", "NetworkPolicy"),
+		ObjectMeta: objectMeta(p),
+		Spec:       networkingv1.NetworkPolicySpec{PodSelector: p.PodSelector.toK8s()},
+	}
+	for _, t := range p.PolicyTypes() {
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, networkingv1.PolicyType(t))
+	}
+	for _, r := range p.Ingress {
+		np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  networkPolicyPeers(r.Peers),
+			Ports: networkPolicyPorts(r.Ports),
+		})
+	}
+	for _, r := range p.Egress {
+		np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			To:    networkPolicyPeers(r.Peers),
+			Ports: networkPolicyPorts(r.Ports),
+		})
+	}
+	return np
+}
+
+// networkPolicyPeers converts peers into ipBlocks, namespaceSelectors on the namespace name
+// (combined with the peer podSelector) or podSelectors in the policy's own namespace
+func networkPolicyPeers(peers []GenericPeer) []networkingv1.NetworkPolicyPeer {
+	var out []networkingv1.NetworkPolicyPeer
+	for _, peer := range peers {
+		var np networkingv1.NetworkPolicyPeer
+		switch {
+		case peer.CIDR != "":
+			np.IPBlock = &networkingv1.IPBlock{CIDR: peer.CIDR, Except: peer.Except}
+		case peer.Namespace != "":
+			ns := namespaceSelector(peer.Namespace).toK8s()
+			np.NamespaceSelector = &ns
+			if peer.PodSelector != nil {
+				sel := peer.PodSelector.toK8s()
+				np.PodSelector = &sel
+			}
+		default:
+			sel := peer.PodSelector.toK8s()
+			np.PodSelector = &sel
+		}
+		out = append(out, np)
+	}
+	return out
+}
+
+// networkPolicyPorts converts ports; named ports are strings and ranges carry an endPort
+func networkPolicyPorts(ports []GenericPort) []networkingv1.NetworkPolicyPort {
+	var out []networkingv1.NetworkPolicyPort
+	for _, port := range ports {
+		protocol := corev1.Protocol(port.Protocol)
+		np := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+		value := intstr.FromString(port.Port)
+		if n, err := strconv.Atoi(port.Port); err == nil {
+			value = intstr.FromInt32(int32(n))
+		}
+		np.Port = &value
+		if port.EndPort != 0 {
+			end := int32(port.EndPort)
+			np.EndPort = &end
+		}
+		out = append(out, np)
+	}
+	return out
+}
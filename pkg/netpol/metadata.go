@@ -0,0 +1,65 @@
+package netpol
+
+import (
+	"circe/pkg/unmarshalcsv"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Annotation keys carrying the audit columns of the sheet on the generated objects
+const (
+	CommentAnnotation = "circe/comment"
+	OwnerAnnotation   = "circe/owner"
+	TicketAnnotation  = "circe/ticket"
+)
+
+// annotationSeparator joins the distinct values of rows merged into one policy
+const annotationSeparator = "; "
+
+// mergeMetadata adds the comment, owner, ticket and labels cells of a row to the policy.
+// Annotation values of grouped rows are merged (distinct values joined with "; "),
+// while labels must agree across the rows of a policy.
+func (p *GenericPolicy) mergeMetadata(d unmarshalcsv.UnmarshalledData) error {
+	for key, value := range map[string]string{
+		CommentAnnotation: d.Comment,
+		OwnerAnnotation:   d.Owner,
+		TicketAnnotation:  d.Ticket,
+	} {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if p.Annotations == nil {
+			p.Annotations = map[string]string{}
+		}
+		existing := p.Annotations[key]
+		if existing == "" {
+			p.Annotations[key] = value
+		} else if !slices.Contains(strings.Split(existing, annotationSeparator), value) {
+			p.Annotations[key] = existing + annotationSeparator + value
+		}
+	}
+
+	for _, pair := range splitAndTrim(d.Labels) {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok {
+			return fmt.Errorf("labels: expected key=value, got %q", pair)
+		}
+		if err := validateLabelKey(k); err != nil {
+			return fmt.Errorf("labels: %w", err)
+		}
+		if err := validateLabelValue(v); err != nil {
+			return fmt.Errorf("labels: %w", err)
+		}
+		if p.Labels == nil {
+			p.Labels = map[string]string{}
+		}
+		if prev, ok := p.Labels[k]; ok && prev != v {
+			return fmt.Errorf("labels: conflicting values %q and %q for label %s", prev, v, k)
+		}
+		p.Labels[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// TestPolicyMetadata ensures comment/owner/ticket/labels cells are carried into the metadata
+// of the generated policy and merged across grouped rows.
+func TestPolicyMetadata(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend-out", Comment: "payments API", Owner: "team-a", Ticket: "SEC-1", Labels: "tier=web"},
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.1.0/24", DestinationPorts: "443", NetworkPolicyName: "frontend-out", Comment: "audit log shipping", Owner: "team-a", Ticket: "SEC-2", Labels: "tier=web,env=prod"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "frontend-out.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	s := string(b)
+	for _, sub := range []string{
+		"labels:\n    env: prod\n    tier: web",
+		"circe/comment: payments API; audit log shipping",
+		"circe/owner: team-a",
+		"circe/ticket: SEC-1; SEC-2",
+	} {
+		if !strings.Contains(s, sub) {
+			t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+		}
+	}
+
+	rows[1].Labels = "tier=api"
+	if _, err := netpol.NewGenericPolicies(rows, outDir); err == nil {
+		t.Fatalf("expected conflicting label error")
+	}
+}
+
+// TestAmbiguousLabelValues ensures values YAML would otherwise read as booleans or nulls are
+// quoted, so the rendered manifest decodes back into the same strings.
+func TestAmbiguousLabelValues(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "enabled=true,mode=on", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "web", Labels: "managed=yes,empty=null"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "web.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	var np networkingv1.NetworkPolicy
+	if err := yaml.UnmarshalStrict(b, &np); err != nil {
+		t.Fatalf("rendered YAML is not a valid NetworkPolicy: %v. Content:\n%s", err, b)
+	}
+	want := map[string]string{"enabled": "true", "mode": "on"}
+	for k, v := range want {
+		if got := np.Spec.PodSelector.MatchLabels[k]; got != v {
+			t.Fatalf("podSelector %s = %q, want %q. Content:\n%s", k, got, v, b)
+		}
+	}
+	if np.Labels["managed"] != "yes" || np.Labels["empty"] != "null" {
+		t.Fatalf("labels not preserved: %v. Content:\n%s", np.Labels, b)
+	}
+}
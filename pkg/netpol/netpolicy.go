@@ -2,96 +2,386 @@ package netpol
 
 import (
 	"circe/pkg/unmarshalcsv"
+	"errors"
 	"fmt"
+	"io"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
-	"text/template"
 )
 
 type NetworkPolicy struct {
-	generic []GenericPolicy
-	output  string
+	generic  []GenericPolicy
+	warnings []Problem
+	output   string
+	opts     Options
 }
 
-// GenericPolicy is a unified representation for both Ingress and Egress policies
-// Direction must be either "Ingress" or "Egress" (title case to match K8s spec)
+// GenericPolicy is a unified representation for both Ingress and Egress policies.
+// Rows sharing the same name and namespace are merged into one policy with one rule per row,
+// kept in per-direction lists so a single policy can carry both Ingress and Egress rules.
 type GenericPolicy struct {
 	Name        string
 	Namespace   string
 	Selector    string
-	SelectorMap map[string]string
-	Direction   string
-	PeerCIDRs   []string
-	Ports       []string
-	Protocols   []string // e.g., ["TCP"], ["UDP"], or ["TCP","UDP"]
+	PodSelector LabelSelector
+	// NodeSelector is set for node-scoped (host) policies built from the node_role column;
+	// such policies are cluster-wide and have no Namespace
+	NodeSelector *LabelSelector
+	Ingress      []GenericRule
+	Egress       []GenericRule
+	Types        []string // explicit policy types; derived from the rule lists when empty
+	Order        *float64 // Calico policy order, from the order column
+	Priority     *int     // AdminNetworkPolicy priority; rendered as the baseline policy when nil
+	Labels       map[string]string
+	Annotations  map[string]string
 }
 
-// NewGenericPolicies builds a unified slice from CSV inputs for both directions
-func NewGenericPolicies(input []unmarshalcsv.UnmarshalledData, output string) *NetworkPolicy {
-	var gp []GenericPolicy
-	for _, d := range input {
+// GenericRule is a single egress/ingress rule entry of a policy
+type GenericRule struct {
+	Action  string // ActionAllow, ActionDeny, ActionPass or ActionLog
+	Peers   []GenericPeer
+	Ports   []GenericPort
+	Methods []string // HTTP methods matched by the rule (Istio only)
+	Paths   []string // HTTP paths matched by the rule (Istio only)
+}
+
+// GenericPort is a single protocol/port pair of a rule. Port is either numeric or a named
+// container port ("http"); EndPort is set for port ranges ("30000-32767") and is zero otherwise.
+type GenericPort struct {
+	Protocol string // "TCP", "UDP" or "SCTP"
+	Port     string
+	EndPort  int
+}
+
+// IsNamed reports whether the port refers to a named container port rather than a number
+func (p GenericPort) IsNamed() bool {
+	_, err := strconv.Atoi(p.Port)
+	return err != nil
+}
+
+// GenericPeer is one entry of a rule's to/from list. It is either an ipBlock (CIDR set),
+// a DNS name (FQDN) or an in-cluster peer selected by namespace and/or pod labels.
+type GenericPeer struct {
+	CIDR        string
+	FQDN        string         // hostname or wildcard pattern ("*.s3.amazonaws.com")
+	Except      []string       // CIDRs excluded from CIDR, each strictly contained in it
+	Namespace   string         // matched via the kubernetes.io/metadata.name label
+	PodSelector *LabelSelector // nil when the peer selects whole namespaces
+}
+
+// PolicyTypes returns the directions covered by the policy ("Ingress" before "Egress", as in K8s)
+func (p GenericPolicy) PolicyTypes() []string {
+	if len(p.Types) > 0 {
+		return p.Types
+	}
+	var types []string
+	if len(p.Ingress) > 0 {
+		types = append(types, "Ingress")
+	}
+	if len(p.Egress) > 0 {
+		types = append(types, "Egress")
+	}
+	return types
+}
+
+// Output formats supported by RenderGeneric
+const (
+	FormatKubernetes = "kubernetes" // networking.k8s.io/v1 NetworkPolicy
+	FormatCilium     = "cilium"     // cilium.io/v2 CiliumNetworkPolicy
+	FormatCalico     = "calico"     // projectcalico.org/v3 NetworkPolicy and GlobalNetworkPolicy
+	FormatANP        = "anp"        // policy.networking.k8s.io/v1alpha1 AdminNetworkPolicy
+	FormatIstio      = "istio"      // security.istio.io/v1 AuthorizationPolicy (ingress only)
+
+	FormatEgressFirewall      = "egressfirewall"      // k8s.ovn.org/v1 EgressFirewall (OVN-Kubernetes)
+	FormatEgressNetworkPolicy = "egressnetworkpolicy" // network.openshift.io/v1 EgressNetworkPolicy (OpenShift SDN)
+)
+
+// ErrNoPolicies is returned by RenderGeneric when no row produced a policy, for example when
+// the input only has rows for the other direction
+var ErrNoPolicies = errors.New("no generic policies defined")
+
+// ClusterWideNamespace in the subject namespace cell selects pods in every namespace. Such
+// policies are rendered as cluster-scoped resources and have no Namespace.
+const ClusterWideNamespace = "*"
+
+// Options tunes how spreadsheet rows are turned into generic policies
+type Options struct {
+	// Direction restricts the rows to "Egress" or "Ingress"; empty keeps both directions
+	Direction string
+	// CanonicalCIDRs masks host bits of CIDRs ("10.0.0.5/24" becomes "10.0.0.0/24")
+	// instead of reporting them as an error
+	CanonicalCIDRs bool
+	// Format selects the output resources rendered by RenderGeneric (FormatKubernetes by default)
+	Format string
+	// NodeFormat selects the resource used for node-scoped policies: "calico" renders a host
+	// endpoint GlobalNetworkPolicy, "cilium" a CiliumClusterwideNetworkPolicy. When empty it
+	// follows Format for the cilium backend and falls back to calico otherwise.
+	NodeFormat string
+	// AllowDNS adds an egress exception to kube-dns in the default-deny-egress policies
+	// built by NewDefaultDenyPolicies
+	AllowDNS bool
+	// DenyAll ends every EgressFirewall/EgressNetworkPolicy with a rule denying all remaining
+	// egress traffic; these formats allow one policy per namespace, so there is no separate
+	// default-deny policy
+	DenyAll bool
+}
+
+// NewGenericPolicies builds a unified slice from CSV inputs for both directions.
+// Rows that share network_policy_name and namespace are grouped into a single policy;
+// an error is returned when such rows disagree on the subject selector.
+func NewGenericPolicies(input []unmarshalcsv.UnmarshalledData, output string) (*NetworkPolicy, error) {
+	return NewGenericPoliciesWithOptions(input, output, Options{})
+}
+
+// NewGenericPoliciesForDirection is like NewGenericPolicies but filters to a single direction ("Egress" or "Ingress")
+func NewGenericPoliciesForDirection(input []unmarshalcsv.UnmarshalledData, output string, direction string) (*NetworkPolicy, error) {
+	return NewGenericPoliciesWithOptions(input, output, Options{Direction: direction})
+}
+
+// NewGenericPoliciesWithOptions is like NewGenericPolicies with explicit Options
+// The egress firewall formats aggregate the rows per namespace instead of per policy name.
+func NewGenericPoliciesWithOptions(input []unmarshalcsv.UnmarshalledData, output string, opts Options) (*NetworkPolicy, error) {
+	if err := opts.CheckFormats(); err != nil {
+		return nil, err
+	}
+	if isEgressFirewallFormat(opts.effectiveFormat(false)) {
+		return newEgressFirewallPolicies(input, output, opts)
+	}
+	var (
+		gp       []GenericPolicy
+		warnings []Problem
+	)
+	index := map[string]int{}
+	for row, d := range input {
+		egress := strings.EqualFold(d.Direction, "egress")
+		ingress := strings.EqualFold(d.Direction, "ingress")
+		if !egress && !ingress {
+			if !isBlankRow(d) {
+				warnings = append(warnings, skippedRow(d, "direction", fmt.Sprintf("unsupported direction %q (expected egress or ingress)", d.Direction)))
+			}
+			continue
+		}
+		if opts.Direction != "" && !strings.EqualFold(opts.Direction, d.Direction) {
+			continue
+		}
 		name := d.NetworkPolicyName
 		if name == "" {
+			warnings = append(warnings, skippedRow(d, "network_policy_name", "network_policy_name is empty"))
 			continue
 		}
 
-		protocols := normalizeProtocols(d.DestinationProtocol)
-		ports := splitAndTrim(d.DestinationPorts)
-
-		if strings.EqualFold(d.Direction, "egress") && d.SourceNamespace != "" && d.SourceSelector != "" {
-   gp = append(gp, GenericPolicy{
-				Name:        name,
-				Namespace:   d.SourceNamespace,
-				Selector:    d.SourceSelector,
-				SelectorMap: parseSelector(d.SourceSelector),
-				Direction:   "Egress",
-				PeerCIDRs:   appendSlash(splitAndTrim(d.DestinationSpecifier)),
-				Ports:       ports,
-				Protocols:   protocols,
-			})
-		} else if strings.EqualFold(d.Direction, "ingress") && d.DestinationNamespace != "" && d.DestinationSelector != "" {
-   gp = append(gp, GenericPolicy{
-				Name:        name,
-				Namespace:   d.DestinationNamespace,
-				Selector:    d.DestinationSelector,
-				SelectorMap: parseSelector(d.DestinationSelector),
-				Direction:   "Ingress",
-				PeerCIDRs:   appendSlash(splitAndTrim(d.SourceSpecifier)),
-				Ports:       ports,
-				Protocols:   protocols,
-			})
-		}
-	}
-	return &NetworkPolicy{generic: gp, output: output}
+		protocols, err := normalizeProtocols(d.DestinationProtocol)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		ports, err := parsePorts(d.DestinationPorts, protocols)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+
+		var p GenericPolicy
+		switch {
+		case strings.TrimSpace(d.NodeRole) != "":
+			// Node-scoped rows select nodes instead of pods and are cluster-wide
+			p = GenericPolicy{Name: name, Selector: d.NodeRole}
+			var sel LabelSelector
+			sel, err = parseNodeRole(d.NodeRole)
+			p.NodeSelector = &sel
+		case egress && d.SourceNamespace != "" && d.SourceSelector != "":
+			p = GenericPolicy{
+				Name:      name,
+				Namespace: d.SourceNamespace,
+				Selector:  d.SourceSelector,
+			}
+			p.PodSelector, err = parseSelector(p.Selector)
+		case ingress && d.DestinationNamespace != "" && d.DestinationSelector != "":
+			p = GenericPolicy{
+				Name:      name,
+				Namespace: d.DestinationNamespace,
+				Selector:  d.DestinationSelector,
+			}
+			p.PodSelector, err = parseSelector(p.Selector)
+		default:
+			warnings = append(warnings, subjectSkipped(d))
+			continue
+		}
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if p.Namespace == ClusterWideNamespace {
+			if format := opts.effectiveFormat(false); format == FormatKubernetes || format == FormatIstio {
+				return nil, rowError(row, d, fmt.Errorf("cluster-wide subject (namespace %q) requires --format calico, cilium or anp", ClusterWideNamespace))
+			}
+			p.Namespace = ""
+		}
+
+		// The format this policy is rendered with, as chosen by render
+		format := opts.effectiveFormat(p.NodeSelector != nil)
+		action, err := parseAction(d.Action)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if err := checkAction(action, format); err != nil {
+			return nil, rowError(row, d, err)
+		}
+
+		rule := GenericRule{Action: action, Ports: ports}
+		rule.Methods, rule.Paths, err = parseHTTPOperation(d, format)
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if egress {
+			rule.Peers, err = buildPeers(d.DestinationSpecifier, d.DestinationNamespace, d.DestinationSelector, opts)
+		} else {
+			rule.Peers, err = buildPeers(d.SourceSpecifier, d.SourceNamespace, d.SourceSelector, opts)
+		}
+		if err == nil {
+			err = checkFQDNPeers(rule, egress, p.NodeSelector != nil, opts)
+		}
+		if err == nil && format == FormatANP {
+			err = checkAdminRule(rule, egress)
+		}
+		if err == nil && format == FormatIstio {
+			err = checkIstioRule(p, rule, egress)
+		}
+		if err != nil {
+			return nil, rowError(row, d, err)
+		}
+
+		key := p.Namespace + "/" + p.Name
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(gp)
+			gp = append(gp, p)
+			i = len(gp) - 1
+		} else if !reflect.DeepEqual(gp[i].PodSelector, p.PodSelector) || !reflect.DeepEqual(gp[i].NodeSelector, p.NodeSelector) {
+			return nil, rowError(row, d, fmt.Errorf("policy %s: conflicting subject selectors %q and %q", key, gp[i].Selector, p.Selector))
+		}
+		if err := gp[i].mergeMetadata(d); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if err := gp[i].mergeOrder(d.Order); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if err := gp[i].mergePriority(d.Priority); err != nil {
+			return nil, rowError(row, d, err)
+		}
+		if egress {
+			gp[i].Egress = append(gp[i].Egress, rule)
+		} else {
+			gp[i].Ingress = append(gp[i].Ingress, rule)
+		}
+	}
+	if opts.effectiveFormat(false) == FormatANP {
+		if err := checkAdminPolicies(gp); err != nil {
+			return nil, err
+		}
+	}
+	if opts.effectiveFormat(false) == FormatIstio {
+		if err := checkIstioPolicies(gp); err != nil {
+			return nil, err
+		}
+	}
+	n := &NetworkPolicy{generic: gp, warnings: warnings, output: output, opts: opts}
+	// Reported before rendering, so nothing is written
+	if _, err := n.fileNames(); err != nil {
+		return nil, err
+	}
+	return n, nil
 }
 
-// NewGenericPoliciesForDirection is like NewGenericPolicies but filters to a single direction ("Egress" or "Ingress")
-func NewGenericPoliciesForDirection(input []unmarshalcsv.UnmarshalledData, output string, direction string) *NetworkPolicy {
-	var filtered []unmarshalcsv.UnmarshalledData
-	for _, d := range input {
-		if strings.EqualFold(direction, "egress") && strings.EqualFold(d.Direction, "egress") {
-			filtered = append(filtered, d)
-		} else if strings.EqualFold(direction, "ingress") && strings.EqualFold(d.Direction, "ingress") {
-			filtered = append(filtered, d)
+// Skipped returns a warning for every row that produced no rule, in sheet order. Rows of the
+// direction excluded by Options.Direction and blank rows are not reported.
+func (netpol *NetworkPolicy) Skipped() []Problem {
+	var skipped []Problem
+	for _, p := range netpol.warnings {
+		if p.Skipped {
+			skipped = append(skipped, p)
 		}
 	}
-	return NewGenericPolicies(filtered, output)
+	return skipped
+}
+
+// Warnings returns the skipped rows along with the rows that were rendered differently than
+// written, such as the subject selectors ignored by the egress firewall formats
+func (netpol *NetworkPolicy) Warnings() []Problem {
+	return netpol.warnings
+}
+
+// Len returns the number of policies RenderGeneric writes
+func (netpol *NetworkPolicy) Len() int {
+	return len(netpol.generic)
+}
+
+// skippedRow returns the warning reporting a row that produced no rule
+func skippedRow(d unmarshalcsv.UnmarshalledData, column, reason string) Problem {
+	return Problem{Severity: SeverityWarning, Sheet: d.Sheet, Row: d.Row, Column: column, Policy: d.NetworkPolicyName, Message: "row skipped: " + reason, Skipped: true}
+}
+
+// subjectSkipped reports an egress or ingress row without subject namespace or selector
+func subjectSkipped(d unmarshalcsv.UnmarshalledData) Problem {
+	side, namespace := "destination", d.DestinationNamespace
+	if strings.EqualFold(d.Direction, "egress") {
+		side, namespace = "source", d.SourceNamespace
+	}
+	column := side + "_selector"
+	if namespace == "" {
+		column = side + "_namespace"
+	}
+	return skippedRow(d, column, fmt.Sprintf("%s row without %s", strings.ToLower(d.Direction), column))
 }
 
-// RenderGeneric renders the generic policies using the unified template
+// isBlankRow reports whether every cell of a row is empty, like the trailing rows of a sheet
+func isBlankRow(d unmarshalcsv.UnmarshalledData) bool {
+	d.Row, d.Sheet = 0, ""
+	return d == unmarshalcsv.UnmarshalledData{}
+}
+
+// rowErr is an error caused by a single spreadsheet row
+type rowErr struct {
+	index int // index of the row in the input of the builder
+	row   unmarshalcsv.UnmarshalledData
+	err   error
+}
+
+func (e *rowErr) Error() string {
+	if e.row.Row > 0 {
+		return fmt.Sprintf("row %d (%s): %v", e.row.Row, e.row.NetworkPolicyName, e.err)
+	}
+	return fmt.Sprintf("%s: %v", e.row.NetworkPolicyName, e.err)
+}
+
+func (e *rowErr) Unwrap() error {
+	return e.err
+}
+
+// rowError annotates err with the spreadsheet row and policy the problem originates from
+func rowError(index int, d unmarshalcsv.UnmarshalledData, err error) error {
+	return &rowErr{index: index, row: d, err: err}
+}
+
+// RenderGeneric renders every generic policy to its own file as the resource selected by
+// Options.Format. Node-scoped policies are rendered with the resource selected by Options.NodeFormat.
 func (netpol *NetworkPolicy) RenderGeneric() error {
-	tmpl := template.Must(template.New("generic").Parse(NetworkPolicyGeneric))
 	if len(netpol.generic) == 0 {
-		return fmt.Errorf("no generic policies defined")
+		return ErrNoPolicies
 	}
-	for _, p := range netpol.generic {
-		f, err := os.Create(fmt.Sprintf("%s/%s.yaml", netpol.output, p.Name))
+	files, err := netpol.fileNames()
+	if err != nil {
+		return err
+	}
+	for i, p := range netpol.generic {
+		f, err := os.Create(filepath.Join(netpol.output, files[i]))
 		if err != nil {
 			return fmt.Errorf("failed to create file: %w", err)
 		}
-		if err := tmpl.Execute(f, p); err != nil {
+		if err := netpol.render(f, p); err != nil {
 			_ = f.Close()
-			return fmt.Errorf("error executing template: %w", err)
+			return fmt.Errorf("failed to render policy %s: %w", p.Name, err)
 		}
 		if err := f.Close(); err != nil {
 			return fmt.Errorf("failed to close file: %w", err)
@@ -100,20 +390,184 @@ func (netpol *NetworkPolicy) RenderGeneric() error {
 	return nil
 }
 
-func appendSlash(in []string) []string {
-	var out []string
-	for _, s := range in {
-		s = strings.TrimSpace(s)
-		if s == "" {
+// fileNames returns the file name of every policy, in order: "<name>.yaml", or
+// "<namespace>-<name>.yaml" when policies of several namespaces share the name, as they would
+// overwrite each other's file. As names may contain '-', the result is checked for duplicates.
+func (netpol *NetworkPolicy) fileNames() ([]string, error) {
+	names := map[string]int{}
+	for _, p := range netpol.generic {
+		names[p.Name]++
+	}
+	var files []string
+	owners := map[string]GenericPolicy{}
+	for _, p := range netpol.generic {
+		fileName := p.Name
+		if names[p.Name] > 1 {
+			scope := p.Namespace
+			if scope == "" {
+				scope = "cluster"
+			}
+			fileName = scope + "-" + p.Name
+		}
+		fileName += ".yaml"
+		if other, ok := owners[fileName]; ok {
+			return nil, fmt.Errorf("policies %s and %s would both be written to %s, rename one of them", policyKey(other), policyKey(p), fileName)
+		}
+		owners[fileName] = p
+		files = append(files, fileName)
+	}
+	return files, nil
+}
+
+// policyKey identifies a policy in messages as namespace/name, or name when cluster-scoped
+func policyKey(p GenericPolicy) string {
+	if p.Namespace == "" {
+		return p.Name
+	}
+	return p.Namespace + "/" + p.Name
+}
+
+// render writes a single policy as the resource matching the output format and its scope
+func (netpol *NetworkPolicy) render(w io.Writer, p GenericPolicy) error {
+	format := netpol.opts.effectiveFormat(p.NodeSelector != nil)
+	if p.NodeSelector != nil {
+		switch format {
+		case FormatCalico:
+			return writeYAML(w, newCalicoPolicy(p))
+		case FormatCilium:
+			return writeYAML(w, newCiliumPolicy(p))
+		default:
+			return fmt.Errorf("unsupported node policy format %q (expected calico or cilium)", netpol.opts.NodeFormat)
+		}
+	}
+	switch format {
+	case FormatKubernetes:
+		return writeYAML(w, newNetworkPolicy(p))
+	case FormatCilium:
+		return writeYAML(w, newCiliumPolicy(p))
+	case FormatCalico:
+		return writeYAML(w, newCalicoPolicy(p))
+	case FormatANP:
+		return writeYAML(w, newAdminPolicy(p))
+	case FormatIstio:
+		return writeYAML(w, newIstioPolicy(p))
+	case FormatEgressFirewall, FormatEgressNetworkPolicy:
+		return writeYAML(w, newEgressFirewall(p, format))
+	default:
+		return fmt.Errorf("unsupported output format %q", netpol.opts.Format)
+	}
+}
+
+// CheckFormats reports an unknown Format or NodeFormat, before any row is read
+func (o Options) CheckFormats() error {
+	switch strings.ToLower(o.Format) {
+	case "", FormatKubernetes, FormatCilium, FormatCalico, FormatANP, FormatIstio, FormatEgressFirewall, FormatEgressNetworkPolicy:
+	default:
+		return fmt.Errorf("unsupported output format %q (expected %s)", o.Format, strings.Join([]string{FormatKubernetes, FormatCilium, FormatCalico, FormatANP, FormatIstio, FormatEgressFirewall, FormatEgressNetworkPolicy}, ", "))
+	}
+	switch strings.ToLower(o.NodeFormat) {
+	case "", FormatCalico, FormatCilium:
+	default:
+		return fmt.Errorf("unsupported node policy format %q (expected %s or %s)", o.NodeFormat, FormatCalico, FormatCilium)
+	}
+	return nil
+}
+
+// effectiveFormat returns the lower-cased output format used for pod policies, or for
+// node-scoped policies when nodeScoped is set: NodeFormat, defaulting to cilium for the cilium
+// backend and to calico otherwise.
+func (o Options) effectiveFormat(nodeScoped bool) string {
+	format := strings.ToLower(o.Format)
+	if !nodeScoped {
+		if format == "" {
+			return FormatKubernetes
+		}
+		return format
+	}
+	if nodeFormat := strings.ToLower(o.NodeFormat); nodeFormat != "" {
+		return nodeFormat
+	}
+	if format == FormatCilium {
+		return FormatCilium
+	}
+	return FormatCalico
+}
+
+// buildPeers combines the CIDR peers of the specifier cell with an in-cluster peer built from
+// the peer-side namespace and selector cells. Each listed namespace becomes its own peer so the
+// pod selector applies within every namespace; a selector without namespace selects pods in the
+// policy's own namespace. A CIDR may exclude sub-blocks with "!" ("10.0.0.0/8!10.96.0.0/12").
+// Hostnames and wildcard patterns ("*.s3.amazonaws.com") become FQDN peers.
+func buildPeers(specifier, namespaces, selector string, opts Options) ([]GenericPeer, error) {
+	var peers []GenericPeer
+	for _, token := range splitAndTrim(specifier) {
+		blocks := strings.Split(token, "!")
+		if fqdn, ok := parseFQDN(blocks[0]); ok {
+			if len(blocks) > 1 {
+				return nil, fmt.Errorf("hostname %q cannot have except blocks", fqdn)
+			}
+			peers = append(peers, GenericPeer{FQDN: fqdn})
 			continue
 		}
-		if strings.Contains(s, "/") {
-			out = append(out, s)
-		} else {
-			out = append(out, fmt.Sprintf("%s/32", s))
+		parent, err := parseCIDR(blocks[0], opts.CanonicalCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		peer := GenericPeer{CIDR: parent.String()}
+		for _, block := range blocks[1:] {
+			except, err := parseCIDR(block, opts.CanonicalCIDRs)
+			if err != nil {
+				return nil, err
+			}
+			if except.Bits() <= parent.Bits() || !parent.Contains(except.Addr()) {
+				return nil, fmt.Errorf("except CIDR %s is not strictly contained in %s", except, parent)
+			}
+			peer.Except = append(peer.Except, except.String())
 		}
+		peers = append(peers, peer)
 	}
-	return out
+	var podSelector *LabelSelector
+	if strings.TrimSpace(selector) != "" {
+		sel, err := parseSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		podSelector = &sel
+	}
+	nsList := splitAndTrim(namespaces)
+	for _, ns := range nsList {
+		peers = append(peers, GenericPeer{Namespace: ns, PodSelector: podSelector})
+	}
+	if len(nsList) == 0 && podSelector != nil {
+		peers = append(peers, GenericPeer{PodSelector: podSelector})
+	}
+	return peers, nil
+}
+
+// parseCIDR parses an IPv4/IPv6 CIDR or host address. Hosts become /32 (IPv4) or /128 (IPv6).
+// A CIDR with host bits set ("10.0.0.5/24") is an error unless canonical is true, in which case
+// the host bits are masked ("10.0.0.0/24").
+func parseCIDR(s string, canonical bool) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil || addr.Zone() != "" {
+			return netip.Prefix{}, fmt.Errorf("invalid IP address %q", s)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+	}
+	if masked := prefix.Masked(); masked != prefix {
+		if !canonical {
+			return netip.Prefix{}, fmt.Errorf("CIDR %q has host bits set (did you mean %s?)", s, masked)
+		}
+		prefix = masked
+	}
+	return prefix, nil
 }
 
 func splitAndTrim(s string) []string {
@@ -131,50 +585,129 @@ func splitAndTrim(s string) []string {
 	return out
 }
 
-func normalizeProtocols(p string) []string {
+// parsePorts expands the destination_ports cell into protocol/port pairs. A token may name its
+// protocol explicitly ("UDP/53"); bare tokens ("443") are paired with every protocol of the
+// destination_protocol cell. Ranges ("8000-8100") are rendered as port + endPort.
+// Duplicate pairs are dropped.
+func parsePorts(s string, protocols []string) ([]GenericPort, error) {
+	seen := map[GenericPort]struct{}{}
+	var out []GenericPort
+	add := func(gp GenericPort) {
+		if _, ok := seen[gp]; !ok {
+			seen[gp] = struct{}{}
+			out = append(out, gp)
+		}
+	}
+	for _, token := range splitAndTrim(s) {
+		portToken := token
+		var explicit string
+		if idx := strings.Index(token, "/"); idx >= 0 {
+			proto, err := parseProtocol(token[:idx])
+			if err != nil {
+				return nil, fmt.Errorf("port %q: %w", token, err)
+			}
+			explicit = proto
+			portToken = strings.TrimSpace(token[idx+1:])
+			if portToken == "" {
+				return nil, fmt.Errorf("missing port in %q", token)
+			}
+		}
+		port, endPort, err := parsePortToken(portToken)
+		if err != nil {
+			return nil, err
+		}
+		if explicit != "" {
+			add(GenericPort{Protocol: explicit, Port: port, EndPort: endPort})
+			continue
+		}
+		for _, proto := range protocols {
+			add(GenericPort{Protocol: proto, Port: port, EndPort: endPort})
+		}
+	}
+	return out, nil
+}
+
+// parsePortToken parses a single port ("443"), named port ("http") or port range ("8000-8100").
+// Numeric ports must be within 1-65535 and a range must satisfy start <= end; a range whose bounds
+// are equal collapses to a single port. Named ports cannot form a range.
+func parsePortToken(token string) (string, int, error) {
+	start, end, isRange := strings.Cut(token, "-")
+	start = strings.TrimSpace(start)
+	startNum, startErr := strconv.Atoi(start)
+	if !isRange || startErr != nil {
+		if startErr == nil && (startNum < 1 || startNum > 65535) {
+			return "", 0, fmt.Errorf("port %q out of range 1-65535", token)
+		}
+		if startErr != nil && !isServiceName(token) {
+			return "", 0, fmt.Errorf("invalid port %q: expected a number, a range or a named port", token)
+		}
+		return token, 0, nil
+	}
+	end = strings.TrimSpace(end)
+	endNum, err := strconv.Atoi(end)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port range %q: ranges require numeric ports", token)
+	}
+	if startNum < 1 || endNum > 65535 {
+		return "", 0, fmt.Errorf("port range %q out of range 1-65535", token)
+	}
+	if startNum > endNum {
+		return "", 0, fmt.Errorf("invalid port range %q: start is greater than end", token)
+	}
+	if startNum == endNum {
+		return start, 0, nil
+	}
+	return start, endNum, nil
+}
+
+// normalizeProtocols parses the destination_protocol cell. An empty cell defaults to TCP;
+// unknown protocols (e.g. ICMP) are reported as an error.
+func normalizeProtocols(p string) ([]string, error) {
 	ps := splitAndTrim(p)
 	seen := map[string]struct{}{}
 	var out []string
 	for _, v := range ps {
-		u := strings.ToUpper(v)
-		if u == "TCP" || u == "UDP" {
-			if _, ok := seen[u]; !ok {
-				seen[u] = struct{}{}
-				out = append(out, u)
-			}
+		u, err := parseProtocol(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[u]; !ok {
+			seen[u] = struct{}{}
+			out = append(out, u)
 		}
 	}
 	if len(out) == 0 {
-		return []string{"TCP"} // default sensible fallback
+		return []string{"TCP"}, nil // default sensible fallback
 	}
-	return out
+	return out, nil
 }
 
-func parseSelector(s string) map[string]string {
-	m := map[string]string{}
-	if s == "" {
-		return m
+// parseProtocol validates a single protocol name against the ones supported by NetworkPolicy
+func parseProtocol(p string) (string, error) {
+	u := strings.ToUpper(strings.TrimSpace(p))
+	switch u {
+	case "TCP", "UDP", "SCTP":
+		return u, nil
+	default:
+		return "", fmt.Errorf("unsupported protocol %q (expected TCP, UDP or SCTP)", p)
 	}
-	parts := strings.Split(s, ",")
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		var k, v string
-		if idx := strings.Index(p, "="); idx >= 0 {
-			k = strings.TrimSpace(p[:idx])
-			v = strings.TrimSpace(p[idx+1:])
-		} else if idx := strings.Index(p, ":"); idx >= 0 { // tolerate already "k: v" style
-			k = strings.TrimSpace(p[:idx])
-			v = strings.TrimSpace(p[idx+1:])
-		} else {
-			k = p
-			v = ""
-		}
-		if k != "" {
-			m[k] = v
+}
+
+// isServiceName reports whether s is a valid named port (IANA_SVC_NAME): at most 15 lowercase
+// alphanumerics or '-', containing at least one letter, without leading, trailing or double '-'.
+func isServiceName(s string) bool {
+	if len(s) == 0 || len(s) > 15 || s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return false
+	}
+	hasLetter := false
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			hasLetter = true
+		case r >= '0' && r <= '9', r == '-':
+		default:
+			return false
 		}
 	}
-	return m
+	return hasLetter
 }
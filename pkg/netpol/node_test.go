@@ -0,0 +1,62 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestNodeScopedPolicies ensures rows with node_role render as host policies selecting nodes
+// instead of pod NetworkPolicies, for both supported node formats.
+func TestNodeScopedPolicies(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", NodeRole: "worker", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "443", NetworkPolicyName: "worker-out"},
+		{Direction: "egress", NodeRole: "worker", DestinationProtocol: "UDP", DestinationPorts: "53", NetworkPolicyName: "worker-out"},
+	}
+
+	cases := map[string][]string{
+		"calico": {
+			"apiVersion: projectcalico.org/v3",
+			"kind: GlobalNetworkPolicy",
+			"selector: has(node-role.kubernetes.io/worker)",
+			"nets:\n      - 10.0.0.0/24",
+			"protocol: UDP",
+		},
+		"cilium": {
+			"apiVersion: cilium.io/v2",
+			"kind: CiliumClusterwideNetworkPolicy",
+			"nodeSelector:\n    matchExpressions:\n    - key: node-role.kubernetes.io/worker\n      operator: Exists",
+			"toCIDRSet:\n    - cidr: 10.0.0.0/24",
+			"toEntities:\n    - all",
+		},
+	}
+	for format, wantSubs := range cases {
+		t.Run(format, func(t *testing.T) {
+			outDir := t.TempDir()
+			gp, err := netpol.NewGenericPoliciesWithOptions(rows, outDir, netpol.Options{NodeFormat: format})
+			if err != nil {
+				t.Fatalf("build generic policies: %v", err)
+			}
+			if err := gp.RenderGeneric(); err != nil {
+				t.Fatalf("render generic: %v", err)
+			}
+			b, err := os.ReadFile(filepath.Join(outDir, "worker-out.yaml"))
+			if err != nil {
+				t.Fatalf("reading rendered file: %v", err)
+			}
+			s := string(b)
+			if strings.Contains(s, "namespace:") || strings.Contains(s, "kind: NetworkPolicy") {
+				t.Fatalf("node policy must be cluster-wide. Content:\n%s", s)
+			}
+			for _, sub := range wantSubs {
+				if !strings.Contains(s, sub) {
+					t.Fatalf("rendered YAML missing substring %q. Content:\n%s", sub, s)
+				}
+			}
+		})
+	}
+}
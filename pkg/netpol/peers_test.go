@@ -0,0 +1,106 @@
+package netpol_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestInClusterPeers ensures peer-side namespace/selector cells render as namespaceSelector
+// and podSelector peers next to the ipBlock peers of the same row.
+func TestInClusterPeers(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationNamespace: "ns-b", DestinationSelector: "app=backend", DestinationSpecifier: "10.0.0.0/24", DestinationPorts: "80", NetworkPolicyName: "frontend-out"},
+		{Direction: "ingress", DestinationNamespace: "ns-b", DestinationSelector: "app=backend", SourceSelector: "app=cache", DestinationPorts: "6379", NetworkPolicyName: "backend-in"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+
+	cases := map[string][]string{
+		"frontend-out.yaml": {
+			"- ipBlock:\n        cidr: 10.0.0.0/24",
+			"- namespaceSelector:\n        matchLabels:\n          kubernetes.io/metadata.name: ns-b\n      podSelector:\n        matchLabels:\n          app: backend",
+		},
+		"backend-in.yaml": {
+			"- from:\n    - podSelector:\n        matchLabels:\n          app: cache",
+		},
+	}
+	for file, wantSubs := range cases {
+		b, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil {
+			t.Fatalf("reading rendered file: %v", err)
+		}
+		s := string(b)
+		for _, sub := range wantSubs {
+			if !strings.Contains(s, sub) {
+				t.Fatalf("%s missing substring %q. Content:\n%s", file, sub, s)
+			}
+		}
+	}
+}
+
+// TestIPBlockExcept ensures "!"-separated exclusions render as ipBlock.except and that
+// exclusions outside of the parent CIDR are rejected.
+func TestIPBlockExcept(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=frontend", DestinationSpecifier: "10.0.0.0/8!10.96.0.0/12!10.100.0.1", DestinationPorts: "443", NetworkPolicyName: "frontend-out"},
+	}
+
+	outDir := t.TempDir()
+	gp, err := netpol.NewGenericPolicies(rows, outDir)
+	if err != nil {
+		t.Fatalf("build generic policies: %v", err)
+	}
+	if err := gp.RenderGeneric(); err != nil {
+		t.Fatalf("render generic: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "frontend-out.yaml"))
+	if err != nil {
+		t.Fatalf("reading rendered file: %v", err)
+	}
+	want := "- ipBlock:\n        cidr: 10.0.0.0/8\n        except:\n        - 10.96.0.0/12\n        - 10.100.0.1/32"
+	if s := string(b); !strings.Contains(s, want) {
+		t.Fatalf("rendered YAML missing substring %q. Content:\n%s", want, s)
+	}
+
+	for _, specifier := range []string{"10.0.0.0/16!10.1.0.0/24", "10.0.0.0/16!10.0.0.0/16", "10.0.0.0/16!10.0.0.0/8"} {
+		rows[0].DestinationSpecifier = specifier
+		if _, err := netpol.NewGenericPolicies(rows, outDir); err == nil {
+			t.Fatalf("expected error for %q", specifier)
+		}
+	}
+}
+
+// TestInvalidPeerReportsRow ensures invalid peer addresses are reported with the originating row.
+func TestInvalidPeerReportsRow(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "invalid.csv")
+	content := "direction,source_namespace,source_selector,destination_specifier,destination_ports,network_policy_name\n" +
+		"egress,ns-a,app=frontend,10.0.0.0/24,80,frontend-out\n" +
+		"egress,ns-a,app=frontend,10.0.0.5/24,80,frontend-out\n"
+	if err := os.WriteFile(csvPath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var rows []unmarshalcsv.UnmarshalledData
+	if err := unmarshalcsv.Unmarshal(&rows, csvPath, 0); err != nil {
+		t.Fatalf("unmarshal csv: %v", err)
+	}
+
+	_, err := netpol.NewGenericPolicies(rows, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "row 3") {
+		t.Fatalf("expected error pointing at row 3, got %v", err)
+	}
+	if _, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{CanonicalCIDRs: true}); err != nil {
+		t.Fatalf("expected canonical CIDRs to be accepted, got %v", err)
+	}
+}
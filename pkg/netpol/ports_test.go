@@ -0,0 +1,128 @@
+package netpol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	cases := []struct {
+		name      string
+		ports     string
+		protocols []string
+		want      []GenericPort
+		wantErr   bool
+	}{
+		{
+			name:      "bare ports single protocol",
+			ports:     "80, 443",
+			protocols: []string{"TCP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "80"}, {Protocol: "TCP", Port: "443"}},
+		},
+		{
+			name:      "bare ports cross product",
+			ports:     "53",
+			protocols: []string{"TCP", "UDP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "53"}, {Protocol: "UDP", Port: "53"}},
+		},
+		{
+			name:      "explicit pairs",
+			ports:     "UDP/53,tcp/53,TCP/443",
+			protocols: []string{"TCP", "UDP"},
+			want:      []GenericPort{{Protocol: "UDP", Port: "53"}, {Protocol: "TCP", Port: "53"}, {Protocol: "TCP", Port: "443"}},
+		},
+		{
+			name:      "explicit and bare mixed without duplicates",
+			ports:     "TCP/443,443",
+			protocols: []string{"TCP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "443"}},
+		},
+		{
+			name:      "port range",
+			ports:     "30000-32767,UDP/8000 - 8100",
+			protocols: []string{"TCP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "30000", EndPort: 32767}, {Protocol: "UDP", Port: "8000", EndPort: 8100}},
+		},
+		{
+			name:      "single port range collapses",
+			ports:     "8080-8080",
+			protocols: []string{"TCP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "8080"}},
+		},
+		{
+			name:      "inverted range",
+			ports:     "8100-8000",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+		{
+			name:      "range with named end",
+			ports:     "8000-http",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+		{
+			name:      "port out of range",
+			ports:     "70000",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+		{
+			name:      "named ports and sctp",
+			ports:     "http,SCTP/metrics,SCTP/3868",
+			protocols: []string{"TCP"},
+			want:      []GenericPort{{Protocol: "TCP", Port: "http"}, {Protocol: "SCTP", Port: "metrics"}, {Protocol: "SCTP", Port: "3868"}},
+		},
+		{
+			name:      "invalid named port",
+			ports:     "Web_Port",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown explicit protocol",
+			ports:     "GRE/47",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+		{
+			name:      "missing port",
+			ports:     "TCP/",
+			protocols: []string{"TCP"},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parsePorts(tc.ports, tc.protocols)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeProtocols(t *testing.T) {
+	got, err := normalizeProtocols("tcp, SCTP,udp,TCP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"TCP", "SCTP", "UDP"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, _ := normalizeProtocols(""); !reflect.DeepEqual(got, []string{"TCP"}) {
+		t.Fatalf("expected TCP default, got %v", got)
+	}
+	if _, err := normalizeProtocols("TCP,ICMP"); err == nil {
+		t.Fatalf("expected error for ICMP")
+	}
+}
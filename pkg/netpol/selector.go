@@ -0,0 +1,218 @@
+package netpol
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelSelector mirrors a Kubernetes label selector: equality requirements end up in
+// MatchLabels, set-based ones ("in", "notin", "!=", exists, does-not-exist) in MatchExpressions.
+type LabelSelector struct {
+	MatchLabels      map[string]string
+	MatchExpressions []SelectorRequirement
+}
+
+// SelectorRequirement is a single set-based selector requirement
+type SelectorRequirement struct {
+	Key      string
+	Operator string // In, NotIn, Exists or DoesNotExist
+	Values   []string
+}
+
+// IsEmpty reports whether the selector has no requirements, i.e. selects everything
+func (s LabelSelector) IsEmpty() bool {
+	return len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+var (
+	setRequirementRe = regexp.MustCompile(`(?i)^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	labelNameRe      = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	dnsSubdomainRe   = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// parseSelector parses a selector cell using the Kubernetes label selector syntax, e.g.
+// "app=web, env in (prod,staging), tier notin (db), !legacy, team". The legacy "k: v"
+// form is accepted as an equality requirement.
+func parseSelector(s string) (LabelSelector, error) {
+	var sel LabelSelector
+	for _, req := range splitRequirements(s) {
+		var (
+			key, op string
+			values  []string
+		)
+		if m := setRequirementRe.FindStringSubmatch(req); m != nil {
+			key = m[1]
+			op = "In"
+			if strings.EqualFold(m[2], "notin") {
+				op = "NotIn"
+			}
+			values = splitAndTrim(m[3])
+			if len(values) == 0 {
+				return LabelSelector{}, fmt.Errorf("selector %q: %s requires at least one value", req, strings.ToLower(m[2]))
+			}
+		} else if k, v, ok := strings.Cut(req, "!="); ok {
+			key, op, values = k, "NotIn", []string{v}
+		} else if k, v, ok := strings.Cut(req, "=="); ok {
+			key, op, values = k, "=", []string{v}
+		} else if k, v, ok := strings.Cut(req, "="); ok {
+			key, op, values = k, "=", []string{v}
+		} else if k, v, ok := strings.Cut(req, ":"); ok { // tolerate already "k: v" style
+			key, op, values = k, "=", []string{v}
+		} else if strings.HasPrefix(req, "!") {
+			key, op = req[1:], "DoesNotExist"
+		} else {
+			key, op = req, "Exists"
+		}
+
+		key = strings.TrimSpace(key)
+		if err := validateLabelKey(key); err != nil {
+			return LabelSelector{}, fmt.Errorf("selector %q: %w", req, err)
+		}
+		for i, v := range values {
+			values[i] = strings.TrimSpace(v)
+			if err := validateLabelValue(values[i]); err != nil {
+				return LabelSelector{}, fmt.Errorf("selector %q: %w", req, err)
+			}
+		}
+
+		if op == "=" {
+			if sel.MatchLabels == nil {
+				sel.MatchLabels = map[string]string{}
+			}
+			if prev, ok := sel.MatchLabels[key]; ok && prev != values[0] {
+				return LabelSelector{}, fmt.Errorf("selector %q: label %s requires both %q and %q", s, key, prev, values[0])
+			}
+			sel.MatchLabels[key] = values[0]
+			continue
+		}
+		sel.MatchExpressions = append(sel.MatchExpressions, SelectorRequirement{Key: key, Operator: op, Values: values})
+	}
+	return sel, nil
+}
+
+// splitRequirements splits a selector on commas that are not enclosed in parentheses
+func splitRequirements(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	out = append(out, s[start:])
+	var reqs []string
+	for _, r := range out {
+		if r = strings.TrimSpace(r); r != "" {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// validateLabelKey checks a label key: an optional DNS subdomain prefix followed by "/" and a
+// name of at most 63 characters made of alphanumerics, '-', '_' or '.'.
+func validateLabelKey(key string) error {
+	name := key
+	if prefix, n, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) == 0 || len(prefix) > 253 || !dnsSubdomainRe.MatchString(prefix) {
+			return fmt.Errorf("invalid label key %q: prefix must be a DNS subdomain", key)
+		}
+		name = n
+	}
+	if len(name) == 0 || len(name) > 63 || !labelNameRe.MatchString(name) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// validateLabelValue checks a label value: empty, or at most 63 alphanumerics, '-', '_' or '.'
+// starting and ending with an alphanumeric character.
+func validateLabelValue(v string) error {
+	if v == "" {
+		return nil
+	}
+	if len(v) > 63 || !labelNameRe.MatchString(v) {
+		return fmt.Errorf("invalid label value %q", v)
+	}
+	return nil
+}
+
+// toK8s converts the selector to its API representation; an empty selector selects everything
+func (s LabelSelector) toK8s() metav1.LabelSelector {
+	out := metav1.LabelSelector{MatchLabels: s.MatchLabels}
+	for _, req := range s.MatchExpressions {
+		out.MatchExpressions = append(out.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      req.Key,
+			Operator: metav1.LabelSelectorOperator(req.Operator),
+			Values:   req.Values,
+		})
+	}
+	return out
+}
+
+// nodeRoleLabelPrefix is the well-known label prefix carrying node roles
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// parseNodeRole turns the node_role cell into a node selector. Plain role names ("worker")
+// require the node-role.kubernetes.io/<role> label; anything else is parsed as a label selector
+// ("topology.kubernetes.io/zone=eu-1a"). Several requirements are ANDed as in any selector.
+func parseNodeRole(s string) (LabelSelector, error) {
+	var reqs []string
+	for _, req := range splitRequirements(s) {
+		if labelNameRe.MatchString(req) && !strings.ContainsAny(req, "=!:/ ") {
+			req = nodeRoleLabelPrefix + req
+		}
+		reqs = append(reqs, req)
+	}
+	sel, err := parseSelector(strings.Join(reqs, ","))
+	if err != nil {
+		return LabelSelector{}, fmt.Errorf("node_role: %w", err)
+	}
+	return sel, nil
+}
+
+// calicoSelector converts a label selector to the Calico selector expression syntax, e.g.
+// "app == 'web' && env in {'prod', 'staging'} && has(team)". An empty selector is "all()".
+func calicoSelector(sel LabelSelector) string {
+	var parts []string
+	keys := make([]string, 0, len(sel.MatchLabels))
+	for k := range sel.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s == '%s'", k, sel.MatchLabels[k]))
+	}
+	for _, req := range sel.MatchExpressions {
+		quoted := make([]string, len(req.Values))
+		for i, v := range req.Values {
+			quoted[i] = "'" + v + "'"
+		}
+		switch req.Operator {
+		case "In":
+			parts = append(parts, fmt.Sprintf("%s in {%s}", req.Key, strings.Join(quoted, ", ")))
+		case "NotIn":
+			parts = append(parts, fmt.Sprintf("%s not in {%s}", req.Key, strings.Join(quoted, ", ")))
+		case "Exists":
+			parts = append(parts, fmt.Sprintf("has(%s)", req.Key))
+		case "DoesNotExist":
+			parts = append(parts, fmt.Sprintf("!has(%s)", req.Key))
+		}
+	}
+	if len(parts) == 0 {
+		return "all()"
+	}
+	return strings.Join(parts, " && ")
+}
@@ -0,0 +1,57 @@
+package netpol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    LabelSelector
+		wantErr bool
+	}{
+		{
+			in:   "app=web, tier: api",
+			want: LabelSelector{MatchLabels: map[string]string{"app": "web", "tier": "api"}},
+		},
+		{
+			in: "app=web,env in (prod, staging),tier notin (db),!legacy,team,zone!=eu",
+			want: LabelSelector{
+				MatchLabels: map[string]string{"app": "web"},
+				MatchExpressions: []SelectorRequirement{
+					{Key: "env", Operator: "In", Values: []string{"prod", "staging"}},
+					{Key: "tier", Operator: "NotIn", Values: []string{"db"}},
+					{Key: "legacy", Operator: "DoesNotExist"},
+					{Key: "team", Operator: "Exists"},
+					{Key: "zone", Operator: "NotIn", Values: []string{"eu"}},
+				},
+			},
+		},
+		{
+			in:   "app.kubernetes.io/name==web",
+			want: LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "web"}},
+		},
+		{in: "", want: LabelSelector{}},
+		{in: "env in ()", wantErr: true},
+		{in: "app=web app", wantErr: true},
+		{in: "Example.com/app=web", wantErr: true},
+		{in: "app=-web", wantErr: true},
+		{in: "app=web,app=api", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseSelector(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %+v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.in, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%q: got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package netpol_test
+
+import (
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestSkippedRows ensures every row that produces no rule is reported with its row and reason,
+// while blank rows and rows of the excluded direction are not.
+func TestSkippedRows(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Row: 2, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "web"},
+		{Row: 3, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8"},
+		{Row: 4, Direction: "egres", SourceNamespace: "ns-a", SourceSelector: "app=web", NetworkPolicyName: "web"},
+		{Row: 5, Direction: "egress", SourceSelector: "app=web", NetworkPolicyName: "web"},
+		{Row: 6, Direction: "egress", SourceNamespace: "ns-a", NetworkPolicyName: "web"},
+		{Row: 7},
+		{Row: 8, Direction: "ingress", NetworkPolicyName: "db"},
+	}
+
+	skippedRows := []string{
+		"row 3, column network_policy_name: row skipped: network_policy_name is empty",
+		`row 4, column direction: row skipped: unsupported direction "egres"`,
+		"row 5, column source_namespace: row skipped: egress row without source_namespace",
+		"row 6, column source_selector: row skipped: egress row without source_selector",
+	}
+	cases := []struct {
+		format string
+		want   []string
+	}{
+		{netpol.FormatKubernetes, skippedRows},
+		// Egress firewalls apply to every pod of the namespace and ignore the selector
+		{netpol.FormatEgressFirewall, skippedRows[:3]},
+	}
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			n, err := netpol.NewGenericPoliciesWithOptions(rows, t.TempDir(), netpol.Options{Direction: "Egress", Format: tc.format})
+			if err != nil {
+				t.Fatalf("build generic policies: %v", err)
+			}
+			skipped, want := n.Skipped(), tc.want
+			if len(skipped) != len(want) {
+				t.Fatalf("expected %d skipped rows, got %v", len(want), skipped)
+			}
+			for i, p := range skipped {
+				if p.Severity != netpol.SeverityWarning || !strings.HasPrefix(p.String(), want[i]) {
+					t.Fatalf("skipped row %d = %s %q, want warning %q", i, p.Severity, p, want[i])
+				}
+			}
+			if n.Len() != 1 {
+				t.Fatalf("expected 1 policy, got %d", n.Len())
+			}
+		})
+	}
+
+	// Without direction filter the ingress row is reported as well, and Validate reports the same
+	if problems := netpol.Validate(rows, netpol.Options{}); len(problems) != 5 || !strings.Contains(problems[4].String(), "ingress row without destination_namespace") {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	// Egress firewalls only render egress rows and report the ingress ones they leave out
+	problems := netpol.Validate(rows, netpol.Options{Format: netpol.FormatEgressFirewall})
+	if len(problems) != 4 || problems[3].Row != 8 || !strings.Contains(problems[3].String(), "format egressfirewall only renders egress rows") {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
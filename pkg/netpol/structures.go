@@ -1,70 +1,41 @@
 package netpol
 
-// Generic template capable of rendering both Ingress and Egress network policies
-const NetworkPolicyGeneric = `
-apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: {{ .Name }}
-  namespace: {{ .Namespace }}
-spec:
-  podSelector:
-    matchLabels:
-    {{- range $k, $v := .SelectorMap }}
-      {{$k}}: {{$v}}
-    {{- end }}
-  policyTypes:
-  - {{ .Direction }}
-  {{- if eq .Direction "Egress" }}
-  egress:
-  - to:
-    {{- range .PeerCIDRs }}
-    - ipBlock:
-        cidr: {{ . }}
-    {{- end }}
-    {{- if .Ports }}
-    ports:
-    {{- if eq (len .Protocols) 1 }}
-    {{- $proto := index .Protocols 0 }}
-    {{- range .Ports }}
-    - protocol: {{$proto}}
-      port: {{ . }}
-    {{- end }}
-    {{- else }}
-    {{- range .Ports }}
-    - protocol: TCP
-      port: {{ . }}
-    {{- end }}
-    {{- range .Ports }}
-    - protocol: UDP
-      port: {{ . }}
-    {{- end }}
-    {{- end }}
-    {{- end }}
-  {{- else }}
-  ingress:
-  - from:
-    {{- range .PeerCIDRs }}
-    - ipBlock:
-        cidr: {{ . }}
-    {{- end }}
-    {{- if .Ports }}
-    ports:
-    {{- if eq (len .Protocols) 1 }}
-    {{- $proto := index .Protocols 0 }}
-    {{- range .Ports }}
-    - protocol: {{$proto}}
-      port: {{ . }}
-    {{- end }}
-    {{- else }}
-    {{- range .Ports }}
-    - protocol: TCP
-      port: {{ . }}
-    {{- end }}
-    {{- range .Ports }}
-    - protocol: UDP
-      port: {{ . }}
-    {{- end }}
-    {{- end }}
-    {{- end }}
-  {{- end }}`
+import (
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// The rendered resources are typed API objects serialised with a YAML encoder, so label values
+// such as "true", "on" or "a:b" are quoted as needed and keys are emitted in a stable order.
+// NetworkPolicy uses the upstream networking.k8s.io/v1 types; the other backends are modelled by
+// the unexported object types next to their converters (calicoPolicy, ciliumPolicy, ...), which
+// reuse the upstream TypeMeta, ObjectMeta and LabelSelector.
+
+// typeMeta returns the apiVersion/kind header of a rendered object
+func typeMeta(apiVersion, kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: apiVersion, Kind: kind}
+}
+
+// objectMeta returns the metadata of a rendered object: name, optional namespace, labels and
+// annotations
+func objectMeta(p GenericPolicy) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        p.Name,
+		Namespace:   p.Namespace,
+		Labels:      p.Labels,
+		Annotations: p.Annotations,
+	}
+}
+
+// writeYAML serialises a rendered object as a YAML document
+func writeYAML(w io.Writer, obj any) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to encode %T: %w", obj, err)
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,262 @@
+package netpol
+
+import (
+	"circe/pkg/unmarshalcsv"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var dnsLabelRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Severities of a Problem: errors prevent rendering, warnings report rows that are skipped or
+// cells that are valid but likely mistyped
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is a single validation finding, located by the sheet, row and column it originates from.
+// Problems spanning several cells or rows have no Column; those about whole policies have no Row.
+type Problem struct {
+	Severity string `json:"severity"`
+	Sheet    string `json:"sheet,omitempty"`
+	Row      int    `json:"row,omitempty"`
+	Column   string `json:"column,omitempty"`
+	Policy   string `json:"policy,omitempty"`
+	Message  string `json:"message"`
+	// Skipped marks the warnings about rows that produced no rule
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+func (p Problem) String() string {
+	var location []string
+	if p.Sheet != "" {
+		location = append(location, "sheet "+p.Sheet)
+	}
+	if p.Row > 0 {
+		location = append(location, fmt.Sprintf("row %d", p.Row))
+	}
+	if p.Column != "" {
+		location = append(location, "column "+p.Column)
+	}
+	if len(location) == 0 {
+		return p.Message
+	}
+	return strings.Join(location, ", ") + ": " + p.Message
+}
+
+// ValidationError reports the problems that prevent rendering
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := []string{fmt.Sprintf("%d problem(s) found", len(e.Problems))}
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the rows NewGenericPoliciesWithOptions would turn into policies and reports
+// all problems at once instead of stopping at the first one. Every cell is checked on its own
+// (CIDRs, ports, protocols, selectors, DNS-1123 names, ...); rows without cell problems are then
+// checked together for what spans several cells or rows, such as peers the output format cannot
+// express or rows of one policy disagreeing on its subject. Rows that produce no rule, such as
+// rows without network_policy_name, are reported as warnings (see NetworkPolicy.Skipped), as
+// are cells that are valid but likely mistyped, such as the port name "80x".
+func Validate(input []unmarshalcsv.UnmarshalledData, opts Options) []Problem {
+	if err := opts.CheckFormats(); err != nil {
+		return []Problem{{Severity: SeverityError, Message: err.Error()}}
+	}
+	var (
+		problems []Problem
+		valid    []unmarshalcsv.UnmarshalledData
+	)
+	for _, d := range input {
+		known := strings.EqualFold(d.Direction, "egress") || strings.EqualFold(d.Direction, "ingress")
+		if known && opts.Direction != "" && !strings.EqualFold(opts.Direction, d.Direction) {
+			continue
+		}
+		// Rows that are skipped anyway are left to the builder, which reports them
+		if known && d.NetworkPolicyName != "" {
+			rowProblems := validateRow(d, opts)
+			problems = append(problems, rowProblems...)
+			if slices.ContainsFunc(rowProblems, func(p Problem) bool { return p.Severity == SeverityError }) {
+				continue
+			}
+		}
+		valid = append(valid, d)
+	}
+
+	// The builder stops at the first error: report it, drop the offending row and try again.
+	// The rows are only rebuilt for what spans several cells or rows, as validateRow already
+	// reported the problems of single cells.
+	for len(valid) > 0 {
+		n, err := NewGenericPoliciesWithOptions(valid, "", opts)
+		if err == nil {
+			problems = append(problems, n.Warnings()...)
+			break
+		}
+		var re *rowErr
+		if !errors.As(err, &re) {
+			problems = append(problems, Problem{Severity: SeverityError, Message: err.Error()})
+			break
+		}
+		problems = append(problems, Problem{Severity: SeverityError, Sheet: re.row.Sheet, Row: re.row.Row, Policy: re.row.NetworkPolicyName, Message: re.err.Error()})
+		valid = slices.Delete(valid, re.index, re.index+1)
+	}
+
+	// Problems about whole policies come last
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		if (a.Row == 0) != (b.Row == 0) {
+			if a.Row == 0 {
+				return 1
+			}
+			return -1
+		}
+		return a.Row - b.Row
+	})
+	return problems
+}
+
+// validateRow checks every cell of an egress or ingress row on its own
+func validateRow(d unmarshalcsv.UnmarshalledData, opts Options) []Problem {
+	var problems []Problem
+	report := func(column string, err error) {
+		problems = append(problems, Problem{Severity: SeverityError, Sheet: d.Sheet, Row: d.Row, Column: column, Policy: d.NetworkPolicyName, Message: err.Error()})
+	}
+	warn := func(column string, err error) {
+		problems = append(problems, Problem{Severity: SeverityWarning, Sheet: d.Sheet, Row: d.Row, Column: column, Policy: d.NetworkPolicyName, Message: err.Error()})
+	}
+
+	// Column names of the subject and peer sides of the row
+	subjectNs, subjectSel := "destination_namespace", "destination_selector"
+	peerSpec, peerNs, peerSel := "source_specifier", "source_namespace", "source_selector"
+	if strings.EqualFold(d.Direction, "egress") {
+		subjectNs, subjectSel = "source_namespace", "source_selector"
+		peerSpec, peerNs, peerSel = "destination_specifier", "destination_namespace", "destination_selector"
+	}
+	cells := map[string]string{
+		"source_specifier":      d.SourceSpecifier,
+		"source_namespace":      d.SourceNamespace,
+		"source_selector":       d.SourceSelector,
+		"destination_specifier": d.DestinationSpecifier,
+		"destination_namespace": d.DestinationNamespace,
+		"destination_selector":  d.DestinationSelector,
+	}
+
+	if err := validateName(d.NetworkPolicyName); err != nil {
+		report("network_policy_name", err)
+	}
+
+	// Subject: the selected nodes, or the selected pods of one namespace
+	if strings.TrimSpace(d.NodeRole) != "" {
+		if sel, err := parseNodeRole(d.NodeRole); err != nil {
+			report("node_role", err)
+		} else if sel.IsEmpty() {
+			report("node_role", fmt.Errorf("node role %q has no requirements", d.NodeRole))
+		}
+	} else {
+		if cells[subjectNs] != "" && cells[subjectNs] != ClusterWideNamespace {
+			if err := validateNamespace(cells[subjectNs]); err != nil {
+				report(subjectNs, err)
+			}
+		}
+		if cells[subjectSel] != "" {
+			if err := validateSelector(cells[subjectSel]); err != nil {
+				report(subjectSel, err)
+			}
+		}
+	}
+
+	// Peers
+	if _, err := buildPeers(cells[peerSpec], "", "", opts); err != nil {
+		report(peerSpec, err)
+	}
+	for _, ns := range splitAndTrim(cells[peerNs]) {
+		if err := validateNamespace(ns); err != nil {
+			report(peerNs, err)
+		}
+	}
+	if strings.TrimSpace(cells[peerSel]) != "" {
+		if err := validateSelector(cells[peerSel]); err != nil {
+			report(peerSel, err)
+		}
+	}
+
+	// Ports, defaulting to TCP when the protocol cell is itself invalid
+	protocols, err := normalizeProtocols(d.DestinationProtocol)
+	if err != nil {
+		report("destination_protocol", err)
+		protocols = []string{"TCP"}
+	}
+	if ports, err := parsePorts(d.DestinationPorts, protocols); err != nil {
+		report("destination_ports", err)
+	} else {
+		for _, port := range ports {
+			// "80x" is a valid port name, but far more likely a mistyped port number
+			if _, err := strconv.Atoi(port.Port); err != nil && port.Port[0] >= '0' && port.Port[0] <= '9' {
+				warn("destination_ports", fmt.Errorf("named port %q starts with a digit, did you mistype a port number?", port.Port))
+				break
+			}
+		}
+	}
+
+	// Backend-specific and metadata columns
+	format := opts.effectiveFormat(strings.TrimSpace(d.NodeRole) != "")
+	if action, err := parseAction(d.Action); err != nil {
+		report("action", err)
+	} else if err := checkAction(action, format); err != nil {
+		report("action", err)
+	}
+	if err := (&GenericPolicy{}).mergeOrder(d.Order); err != nil {
+		report("order", err)
+	}
+	if err := (&GenericPolicy{}).mergePriority(d.Priority); err != nil {
+		report("priority", err)
+	}
+	if err := (&GenericPolicy{}).mergeMetadata(unmarshalcsv.UnmarshalledData{Labels: d.Labels}); err != nil {
+		report("labels", err)
+	}
+	if _, _, err := parseHTTPOperation(unmarshalcsv.UnmarshalledData{HTTPMethods: d.HTTPMethods}, format); err != nil {
+		report("http_methods", err)
+	}
+	if _, _, err := parseHTTPOperation(unmarshalcsv.UnmarshalledData{HTTPPaths: d.HTTPPaths}, format); err != nil {
+		report("http_paths", err)
+	}
+	return problems
+}
+
+// validateName checks a policy name is a DNS-1123 subdomain, as required for object names
+func validateName(name string) error {
+	if len(name) > 253 || !dnsSubdomainRe.MatchString(name) {
+		return fmt.Errorf("invalid name %q: expected a DNS-1123 subdomain (lowercase alphanumerics, '-' or '.')", name)
+	}
+	return nil
+}
+
+// validateNamespace checks a namespace is a DNS-1123 label
+func validateNamespace(ns string) error {
+	if len(ns) > 63 || !dnsLabelRe.MatchString(ns) {
+		return fmt.Errorf("invalid namespace %q: expected a DNS-1123 label (at most 63 lowercase alphanumerics or '-')", ns)
+	}
+	return nil
+}
+
+// validateSelector parses a selector cell and rejects one without requirements, such as ",",
+// which would silently select every pod
+func validateSelector(s string) error {
+	sel, err := parseSelector(s)
+	if err != nil {
+		return err
+	}
+	if sel.IsEmpty() {
+		return fmt.Errorf("selector %q has no requirements and would select every pod", s)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package netpol_test
+
+import (
+	"strings"
+	"testing"
+
+	"circe/pkg/netpol"
+	"circe/pkg/unmarshalcsv"
+)
+
+// TestValidate ensures every problem is reported at once, located by row and column, including
+// the ones spanning several rows.
+func TestValidate(t *testing.T) {
+	rows := []unmarshalcsv.UnmarshalledData{
+		{Row: 2, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/33", DestinationPorts: "http,80x", NetworkPolicyName: "Web_Out"},
+		{Row: 3, Direction: "egress", SourceNamespace: "NS_A", SourceSelector: ",", DestinationSpecifier: "10.0.0.0/8", DestinationProtocol: "ICMP", NetworkPolicyName: "web-out"},
+		{Row: 4, Direction: "ingress", DestinationNamespace: "ns-b", DestinationSelector: "app=db", SourceSpecifier: "10.0.0.0/8", DestinationPorts: "5432", NetworkPolicyName: "db"},
+		{Row: 5, Direction: "ingress", DestinationNamespace: "ns-b", DestinationSelector: "app=other", SourceSpecifier: "10.0.0.0/8", DestinationPorts: "5432", NetworkPolicyName: "db"},
+		{Row: 6, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "api.example.com", NetworkPolicyName: "fqdn"},
+		{Row: 7, Direction: "egress", SourceNamespace: "ns-a", SourceSelector: "app=web", DestinationSpecifier: "10.0.0.0/8", NetworkPolicyName: "ok"},
+	}
+
+	want := []string{
+		"row 2, column network_policy_name: invalid name",
+		"row 2, column destination_specifier: invalid CIDR",
+		`row 2, column destination_ports: named port "80x"`,
+		"row 3, column source_namespace: invalid namespace",
+		"row 3, column source_selector: selector \",\" has no requirements",
+		"row 3, column destination_protocol: unsupported protocol",
+		"row 5: policy ns-b/db: conflicting subject selectors",
+		"row 6: hostname \"api.example.com\"",
+	}
+	problems := netpol.Validate(rows, netpol.Options{})
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for i, p := range problems {
+		if !strings.HasPrefix(p.String(), want[i]) {
+			t.Fatalf("problem %d = %q, want prefix %q", i, p, want[i])
+		}
+	}
+	// "80x" is a legal port name, so it is only a warning
+	if problems[2].Severity != netpol.SeverityWarning || problems[2].Skipped {
+		t.Fatalf("expected a warning for the mistyped port, got %+v", problems[2])
+	}
+
+	// The same rows are fine for a format that can express them, once the cells are fixed
+	if problems := netpol.Validate(rows[5:], netpol.Options{Format: netpol.FormatCilium}); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+	// Unknown formats are reported without checking the rows
+	if problems := netpol.Validate(rows, netpol.Options{Format: "foo"}); len(problems) != 1 || !strings.Contains(problems[0].Message, `unsupported output format "foo"`) {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	// Rows of the other direction are not checked
+	if problems := netpol.Validate(rows[:2], netpol.Options{Direction: "Ingress"}); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
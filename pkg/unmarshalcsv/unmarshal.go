@@ -34,6 +34,25 @@ type UnmarshalledData struct {
 	Comment              string `csv:"comment" ommitempty:"true"`
 	NetworkPolicyName    string `csv:"network_policy_name" ommitempty:"true"`
 
+	// Optional audit columns carried into the metadata of the generated policies
+	Owner  string `csv:"owner" ommitempty:"true"`
+	Ticket string `csv:"ticket" ommitempty:"true"`
+	Labels string `csv:"labels" ommitempty:"true"` // comma-separated key=value pairs
+
+	// Optional backend-specific columns
+	Order    string `csv:"order" ommitempty:"true"`    // Calico policy order (lower is evaluated first)
+	Action   string `csv:"action" ommitempty:"true"`   // allow (default), deny, pass or log
+	Priority string `csv:"priority" ommitempty:"true"` // AdminNetworkPolicy priority (0-1000)
+
+	// Optional L7 columns, comma-separated (Istio AuthorizationPolicy only)
+	HTTPMethods string `csv:"http_methods" ommitempty:"true"`
+	HTTPPaths   string `csv:"http_paths" ommitempty:"true"`
+
+	// Row is the 1-based line of the record in the source sheet, used to point at the origin of errors
+	Row int `csv:"-" rownum:"true"`
+	// Sheet is the name of the XLSX sheet the record was read from; empty for CSV input
+	Sheet string `csv:"-" sheet:"true"`
+
 	// Generic aliases (not bound to CSV headers) populated via Normalize()
 	// These allow downstream code to be direction-agnostic.
 	PolicyName       string `csv:"-"` // alias for NetworkPolicyName
@@ -45,6 +64,19 @@ type UnmarshalledData struct {
 	Role             string `csv:"-"` // alias for NodeRole
 }
 
+// Header returns the canonical header of an input sheet: the csv tags of UnmarshalledData in
+// declaration order
+func Header() []string {
+	t := reflect.TypeOf(UnmarshalledData{})
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("csv"); tag != "" && tag != "-" {
+			header = append(header, tag)
+		}
+	}
+	return header
+}
+
 // NewUnmarshalCsv keeps backward compatibility for CSV files
 func NewUnmarshalCsv(fileName string, headerStart int) (*UnmarshalCsv, error) {
 	reader, err := os.Open(fileName)
@@ -77,7 +109,7 @@ func (u *UnmarshalCsv) UnmarshalCsv(out interface{}) error {
 	if err != nil {
 		return fmt.Errorf("unmarshalcsv, failed to read csv data: %w", err)
 	}
-	return unmarshalRecords(out, records, u.headerStart)
+	return unmarshalRecords(out, records, u.headerStart, "")
 }
 
 // unmarshalXlsx reads the first sheet of an .xlsx file and maps rows to the struct slice
@@ -98,11 +130,12 @@ func unmarshalXlsx(out interface{}, fileName string, headerStart int) error {
 	if len(rows) == 0 {
 		return fmt.Errorf("unmarshalxlsx, file has no data")
 	}
-	return unmarshalRecords(out, rows, headerStart)
+	return unmarshalRecords(out, rows, headerStart, sheets[0])
 }
 
-// unmarshalRecords maps a matrix of strings (records) to the provided slice of structs using `csv` tags
-func unmarshalRecords(out interface{}, records [][]string, headerStart int) error {
+// unmarshalRecords maps a matrix of strings (records) to the provided slice of structs using `csv` tags.
+// Fields tagged `rownum:"true"` and `sheet:"true"` receive the line and the sheet of each record.
+func unmarshalRecords(out interface{}, records [][]string, headerStart int, sheet string) error {
 	outValue := reflect.ValueOf(out)
 	if outValue.Kind() != reflect.Ptr || outValue.Elem().Kind() != reflect.Slice {
 		return fmt.Errorf("unmarshalcsv: out must be a pointer to a slice")
@@ -125,6 +158,15 @@ func unmarshalRecords(out interface{}, records [][]string, headerStart int) erro
 			}
 		}
 	}
+	rowField, sheetField := -1, -1
+	for j := 0; j < sliceElementType.NumField(); j++ {
+		if sliceElementType.Field(j).Tag.Get("rownum") == "true" {
+			rowField = j
+		}
+		if sliceElementType.Field(j).Tag.Get("sheet") == "true" {
+			sheetField = j
+		}
+	}
 	dataRows := [][]string{}
 	if len(records) > headerStart+1 {
 		dataRows = records[headerStart+1:]
@@ -132,6 +174,12 @@ func unmarshalRecords(out interface{}, records [][]string, headerStart int) erro
 	slice := reflect.MakeSlice(outValue.Elem().Type(), len(dataRows), len(dataRows))
 	for i, row := range dataRows {
 		structInstance := slice.Index(i)
+		if rowField >= 0 {
+			structInstance.Field(rowField).SetInt(int64(headerStart + i + 2))
+		}
+		if sheetField >= 0 {
+			structInstance.Field(sheetField).SetString(sheet)
+		}
 		for csvIndex, csvValue := range row {
 			if structFieldIndex, ok := headerMap[csvIndex]; ok {
 				field := structInstance.Field(structFieldIndex)
@@ -22,6 +22,9 @@ func TestUnmarshalCsv_UnmarshalCsv(t *testing.T) {
 	if len(n) == 0 {
 		t.Fatalf("expected rows, got 0")
 	}
+	if n[0].Row != 2 {
+		t.Fatalf("expected first data row to be line 2, got %d", n[0].Row)
+	}
 }
 
 func TestUnmarshal_Generic_XLSX(t *testing.T) {
@@ -59,6 +62,9 @@ func TestUnmarshal_Generic_XLSX(t *testing.T) {
 	if out[0].Direction != "egress" || out[0].DestinationNamespace != "ns-b" {
 		t.Fatalf("unexpected data: %+v", out[0])
 	}
+	if out[0].Sheet != sheet || out[0].Row != 2 {
+		t.Fatalf("expected row 2 of sheet %s, got row %d of sheet %q", sheet, out[0].Row, out[0].Sheet)
+	}
 
 	// also test generic CSV path via Unmarshal
 	csvPath := filepath.Join("testdata", "sample.csv")
@@ -71,3 +77,27 @@ func TestUnmarshal_Generic_XLSX(t *testing.T) {
 	}
 	_ = os.Remove(file)
 }
+
+func TestHeader(t *testing.T) {
+	header := Header()
+	if header[0] != "direction" || header[len(header)-1] != "http_paths" {
+		t.Fatalf("unexpected header order: %v", header)
+	}
+	for _, col := range header {
+		if col == "-" || col == "" {
+			t.Fatalf("header must only contain bound columns: %v", header)
+		}
+	}
+	// The canonical header binds every cell of a row
+	row := make([]string, len(header))
+	for i := range row {
+		row[i] = "x"
+	}
+	var out []UnmarshalledData
+	if err := unmarshalRecords(&out, [][]string{header, row}, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if out[0].Direction != "x" || out[0].HTTPPaths != "x" || out[0].NetworkPolicyName != "x" {
+		t.Fatalf("unexpected data: %+v", out[0])
+	}
+}